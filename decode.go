@@ -1,152 +1,1086 @@
 package toml
 
-import ( 
-	"runtime"
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
 	"reflect"
+	"runtime"
+	"strconv"
 	"strings"
 	"time"
-	"fmt"
 )
 
-var timeType = reflect.TypeOf(time.Time{})
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	numberType   = reflect.TypeOf(Number(""))
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+	bigRatType   = reflect.TypeOf(big.Rat{})
 
-func Unmarshal(data string, v interface{}) (err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			if _, ok := r.(runtime.Error); ok {
-				panic(r)
-			}
-			err = r.(error)
-		}
-	}()
+	localDateType     = reflect.TypeOf(Date{})
+	localTimeType     = reflect.TypeOf(TimeOfDay{})
+	localDateTimeType = reflect.TypeOf(DateTime{})
+
+	orderedMapType     = reflect.TypeOf(OrderedMap{})
+	emptyInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+	genericMapType     = reflect.TypeOf(map[string]interface{}{})
+
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// GenericTypes selects the Go types of values decoded into an
+// interface{}. The zero value selects the defaults.
+type GenericTypes struct {
+	// Integer is the type of integers: an integer or float type, or
+	// Number. The default is int64.
+	Integer reflect.Type
+
+	// Float is the type of floats: a float type or Number. The default
+	// is float64.
+	Float reflect.Type
+
+	// LocalTypes decodes local dates, times and datetimes into Date,
+	// TimeOfDay and DateTime instead of a time.Time in time.Local.
+	LocalTypes bool
+
+	// Table is the type of tables: map[string]interface{}, the default,
+	// or *OrderedMap.
+	Table reflect.Type
+}
+
+// check returns an error if g selects an unsupported type.
+func (g GenericTypes) check() error {
+	if t := g.Integer; t != nil && t != numberType && !isIntKind(t.Kind()) && !isFloatKind(t.Kind()) {
+		return fmt.Errorf("toml: cannot decode generic integers into %s", t)
+	}
+	if t := g.Float; t != nil && t != numberType && !isFloatKind(t.Kind()) {
+		return fmt.Errorf("toml: cannot decode generic floats into %s", t)
+	}
+	if t := g.Table; t != nil && t != genericMapType && t != reflect.PtrTo(orderedMapType) {
+		return fmt.Errorf("toml: cannot decode generic tables into %s", t)
+	}
+	return nil
+}
+
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
 
-	tree, e := Parse(data) 
-	if e != nil { return e }
+func isFloatKind(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+// A Number represents a TOML integer or float literal, kept as text so
+// that no precision is lost.
+type Number string
+
+// String returns the literal text of the number.
+func (n Number) String() string { return string(n) }
+
+// Int64 returns the number as an int64.
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+// Float64 returns the number as a float64.
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// Unmarshal parses the TOML document data and stores the result in the
+// value pointed to by v.
+func Unmarshal(data []byte, v interface{}) error {
+	return new(Decoder).decode(string(data), v)
+}
+
+// DecodeFile reads the TOML file at path and stores the result in the
+// value pointed to by v. Errors are prefixed with path.
+func DecodeFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// A DecodeHook is consulted before a value node is decoded into v. It
+// reports whether it has stored the value itself. An error it returns is
+// prefixed with the path and line of the value, and stays available to
+// errors.Is.
+type DecodeHook func(node Node, v reflect.Value) (bool, error)
+
+// A ConvertFunc converts a value node into a Go value.
+type ConvertFunc func(node Node) (interface{}, error)
+
+// A convertKey selects the ConvertFunc for nodes of a type decoded into
+// values of a Go type.
+type convertKey struct {
+	from NodeType
+	to   reflect.Type
+}
 
-	d := &decode{}
+// A Decoder reads and decodes a TOML document from an input stream.
+type Decoder struct {
+	r             io.Reader
+	strict        bool
+	caseSensitive bool
+	naming        NameFunc
+	generic       GenericTypes
+	hooks         []DecodeHook
+	pathHooks     map[string][]DecodeHook
+	converters    map[convertKey]ConvertFunc
+	warning       func(Warning)
+	dialect       Dialect
+	allErrors     bool
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// Strict makes Decode return an error when the document has a key or
+// table that has no matching struct field.
+func (dec *Decoder) Strict() {
+	dec.strict = true
+}
+
+// AllErrors makes Decode go on after a value of the wrong type, an
+// unknown key in strict mode or a missing required key, and return all
+// of them as DecodeErrors. Other errors still stop the decoding.
+func (dec *Decoder) AllErrors() {
+	dec.allErrors = true
+}
+
+// CaseSensitive makes Decode match keys to struct field names exactly,
+// instead of ignoring case.
+func (dec *Decoder) CaseSensitive() {
+	dec.caseSensitive = true
+}
+
+// Naming sets how the keys of struct fields without a tag name are
+// formed, SnakeCase for example. By default the key is the field name.
+// Keys still match ignoring case unless CaseSensitive is set.
+func (dec *Decoder) Naming(f NameFunc) {
+	dec.naming = f
+}
+
+// UseNumber makes Decode store numbers in an interface{} as a Number
+// instead of an int64 or float64.
+func (dec *Decoder) UseNumber() {
+	dec.generic.Integer = numberType
+	dec.generic.Float = numberType
+}
+
+// UseOrderedMap makes Decode store tables in an interface{} as an
+// *OrderedMap keeping the order of the document, instead of a
+// map[string]interface{}.
+func (dec *Decoder) UseOrderedMap() {
+	dec.generic.Table = reflect.PtrTo(orderedMapType)
+}
+
+// GenericTypes sets the Go types of values decoded into an interface{}.
+func (dec *Decoder) GenericTypes(g GenericTypes) {
+	dec.generic = g
+}
+
+// Dialect sets the syntax accepted by Decode. The default is standard TOML.
+func (dec *Decoder) Dialect(d Dialect) {
+	dec.dialect = d
+}
+
+// Hook adds a hook consulted, in the order added, for every value decoded.
+func (dec *Decoder) Hook(h DecodeHook) {
+	dec.hooks = append(dec.hooks, h)
+}
+
+// HookPath adds a hook consulted for the value at path, written as in
+// error messages: logging.level or servers[0].port. Hooks for the path
+// are consulted before any other.
+func (dec *Decoder) HookPath(path string, h DecodeHook) {
+	if dec.pathHooks == nil {
+		dec.pathHooks = make(map[string][]DecodeHook)
+	}
+	dec.pathHooks[path] = append(dec.pathHooks[path], h)
+}
+
+// HookType makes f convert nodes of type from decoded into values of type
+// to, after the hooks added with HookPath and Hook have declined them.
+// The result of f must be assignable or convertible to to, but not be an
+// integer converted to a string. A pointer type matches before the
+// pointer is allocated and its element type after.
+func (dec *Decoder) HookType(from NodeType, to reflect.Type, f ConvertFunc) {
+	if dec.converters == nil {
+		dec.converters = make(map[convertKey]ConvertFunc)
+	}
+	dec.converters[convertKey{from, to}] = f
+}
+
+// Warn sets a function called for each use of a deprecated key or of an
+// alias of a key. Such keys are accepted silently by default.
+func (dec *Decoder) Warn(f func(Warning)) {
+	dec.warning = f
+}
+
+// A Warning reports a key the decoder accepted but that should be
+// changed.
+type Warning struct {
+	Path    string // path of the key: server.bind
+	Line    int    // line of the key in the document
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s (line %d): %s", w.Path, w.Line, w.Message)
+}
+
+// Decode reads the whole TOML document from its input and stores the
+// result in the value pointed to by v.
+func (dec *Decoder) Decode(v interface{}) error {
+	data, err := io.ReadAll(dec.r)
+	if err != nil {
+		return err
+	}
+	return dec.decode(string(data), v)
+}
+
+func (dec *Decoder) decode(data string, v interface{}) (err error) {
+	defer decodeRecover(&err)
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("toml: decode target must be a non-nil pointer, not %s", reflect.TypeOf(v))
+	}
+
+	if err := dec.generic.check(); err != nil {
+		return err
+	}
+
+	tree, e := ParseDialect(data, dec.dialect)
+	if e != nil {
+		return e
+	}
+
+	d := dec.newDecode(tree)
+	d.defaults(rv.Elem())
+	d.top(rv.Elem(), tree.Root)
+
+	return d.check(rv.Elem())
+}
+
+// newDecode returns the state for decoding tree, which may be nil, with
+// the options of dec.
+func (dec *Decoder) newDecode(tree *Tree) *decode {
+	d := &decode{
+		tree:          tree,
+		strict:        dec.strict,
+		caseSensitive: dec.caseSensitive,
+		naming:        dec.naming,
+		warning:       dec.warning,
+		generic:       dec.generic,
+		hooks:         dec.hooks,
+		pathHooks:     dec.pathHooks,
+		converters:    dec.converters,
+	}
+	if tree != nil {
+		d.paths = tablePaths(tree.Root)
+	}
+	if dec.allErrors {
+		d.errs = &DecodeErrors{}
+	}
+	return d
+}
 
-	rv := reflect.Indirect(reflect.ValueOf(v))
-	if rv.Kind() == reflect.Interface && rv.NumMethod() == 0 {
-		// Decoding into nil interface.
-		newv := reflect.ValueOf(make(map[string]interface{}))
-		d.top(newv, tree.Root)
-		rv.Set(newv)
-	} else {
-		d.top(reflect.Indirect(reflect.ValueOf(v)), tree.Root)
+// decodeRecover turns the panic aborting a decoding into an error.
+func decodeRecover(errp *error) {
+	if r := recover(); r != nil {
+		if _, ok := r.(runtime.Error); ok {
+			panic(r)
+		}
+		*errp = r.(error)
 	}
+}
 
-	return 
+// check reports the required keys missing below v, then returns the
+// errors collected, if any, or the failures of validating v.
+func (d *decode) check(v reflect.Value) error {
+	d.required(v)
+	if d.errs != nil && len(*d.errs) > 0 {
+		return *d.errs
+	}
+	return d.validate(v)
 }
 
-// An UnmarshalTypeError describes a JSON value that was
+// An UnmarshalTypeError describes a TOML value that was
 // not appropriate for a value of a specific Go type.
 type UnmarshalTypeError struct {
-	Value string       // description of JSON value - "bool", "array", "number -5"
-	Type  reflect.Type // type of Go value it could not be assigned to
+	Value  string       // description of TOML value - "bool true", "string \"x\"", "array"
+	Type   reflect.Type // type of Go value it could not be assigned to
+	Path   []string     // keys and array indexes leading to the value - servers, [1], port
+	Field  string       // struct fields leading to the Go value - "Servers.Port"
+	Line   int          // line of the value in the document, or 0 if unknown
+	Column int          // column of the value, counted in bytes from 1
 }
 
 func (e *UnmarshalTypeError) Error() string {
-	return "toml: cannot unmarshal " + e.Value + " into Go value of type " + e.Type.String()
+	return "toml: " + where(pathString(e.Path), e.Line) + "cannot decode " + e.Value + " into " + e.Type.String()
+}
+
+// Unwrap returns ErrTypeMismatch.
+func (e *UnmarshalTypeError) Unwrap() error {
+	return ErrTypeMismatch
+}
+
+// An UnknownKeyError describes a key that has no matching struct field,
+// in strict mode.
+type UnknownKeyError struct {
+	Path        []string // keys and array indexes leading to the key, the key last
+	Line        int      // line of the key in the document
+	Column      int      // column of the key, counted in bytes from 1
+	Suggestions []string // keys of the struct the key may be a misspelling of
+}
+
+func (e *UnknownKeyError) Error() string {
+	msg := "toml: " + where(pathString(e.Path), e.Line) + "unknown key"
+	for i, s := range e.Suggestions {
+		switch {
+		case i == 0:
+			msg += ", did you mean "
+		case i == len(e.Suggestions)-1:
+			msg += " or "
+		default:
+			msg += ", "
+		}
+		msg += strconv.Quote(s)
+	}
+	if len(e.Suggestions) > 0 {
+		msg += "?"
+	}
+	return msg
+}
+
+// Unwrap returns ErrUnknownField.
+func (e *UnknownKeyError) Unwrap() error {
+	return ErrUnknownField
+}
+
+// A MissingKeyError describes a table that lacks a key whose struct field
+// is tagged required.
+type MissingKeyError struct {
+	Path  []string // keys and array indexes leading to the table
+	Key   string   // the missing key
+	Field string   // struct fields leading to the Go value - "Database.Port"
+	Line  int      // line of the table in the document, or 0 for the root
+}
+
+func (e *MissingKeyError) Error() string {
+	return "toml: " + where(pathString(e.Path), e.Line) + "missing required key " + keyText(e.Key)
+}
+
+// Unwrap returns ErrMissingField.
+func (e *MissingKeyError) Unwrap() error {
+	return ErrMissingField
+}
+
+// DecodeErrors is the error Decode returns after AllErrors, listing every
+// error in the order of the document. errors.As and errors.Is look
+// through each of them.
+type DecodeErrors []error
+
+func (e DecodeErrors) Error() string {
+	msgs := []string{}
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the errors listed.
+func (e DecodeErrors) Unwrap() []error {
+	return e
+}
+
+// where formats the location of a value as the start of an error
+// message: "database.port (line 12): ". Either part may be unknown.
+func where(path string, line int) string {
+	switch {
+	case line > 0 && path != "":
+		return fmt.Sprintf("%s (line %d): ", path, line)
+	case line > 0:
+		return fmt.Sprintf("line %d: ", line)
+	case path != "":
+		return path + ": "
+	}
+	return ""
 }
 
 type decode struct {
-	node Node           // current node
+	node          Node     // current node
+	path          []string // keys and array indexes leading to the current node
+	canon         []string // path with the keys of the struct fields matched
+	fields        []string // names of the struct fields on the path, or ""
+	pos           Pos      // position of the current node, or -1 if unknown
+	strict        bool
+	caseSensitive bool
+	naming        NameFunc
+	generic       GenericTypes
+	hooks         []DecodeHook
+	pathHooks     map[string][]DecodeHook
+	converters    map[convertKey]ConvertFunc
+
+	warning func(Warning)
+	names   map[string]keyUse // keys setting fields that have aliases, by table and field
+
+	locations map[string]location // locations of the values decoded, by canonical path
+
+	tree   *Tree                        // the document
+	paths  map[*EntryGroupNode][]string // table paths of the entry groups
+	source string                       // text of the value being decoded, if known
+	done   map[string]bool              // paths of tables decoded by deferred
+
+	errs *DecodeErrors // errors collected with AllErrors, or nil
 }
 
+// A skipValue abandons the decoding of a value whose error was collected.
+type skipValue struct{}
+
 // error aborts the decoding by panicking with err.
 func (d *decode) error(arg interface{}) {
 	panic(arg)
 }
 
-// error aborts the decoding by panicking with err.
-func (d *decode) errorf(format string, args ...interface{}) {
-	panic(fmt.Errorf(format, args...))
+// failf aborts the decoding with an error of category kind about the
+// current node.
+func (d *decode) failf(kind error, format string, args ...interface{}) {
+	d.error(newError(kind, "toml: %s%s", where(pathString(d.path), d.line(d.pos)), fmt.Sprintf(format, args...)))
+}
+
+// report aborts the decoding with err, or collects err and returns with
+// AllErrors.
+func (d *decode) report(err error) {
+	if d.errs == nil {
+		d.error(err)
+	}
+	*d.errs = append(*d.errs, err)
+}
+
+// skip ends the decoding of a value abandoned after its error was
+// collected, restoring the path to its first n elements. It must be
+// deferred.
+func (d *decode) skip(n int) {
+	if r := recover(); r != nil {
+		if _, ok := r.(skipValue); !ok {
+			panic(r)
+		}
+		d.path, d.canon, d.fields = d.path[:n], d.canon[:n], d.fields[:n]
+	}
+}
+
+// typeError aborts the decoding of node into v. A nil node stands for a
+// table declared by a [table] header.
+func (d *decode) typeError(node Node, v reflect.Value) {
+	line, col := d.position(d.pos)
+	d.report(&UnmarshalTypeError{
+		Value:  describeValue(node),
+		Type:   v.Type(),
+		Path:   append([]string(nil), d.path...),
+		Field:  d.fieldPath(),
+		Line:   line,
+		Column: col,
+	})
+	panic(skipValue{})
+}
+
+// fieldPath returns the struct fields leading to the current value,
+// joined with dots.
+func (d *decode) fieldPath() string {
+	fields := []string{}
+	for _, f := range d.fields {
+		if f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return strings.Join(fields, ".")
+}
+
+// describeValue describes node for an error message: its kind, followed
+// by its text for single values.
+func describeValue(node Node) string {
+	switch n := node.(type) {
+	case nil:
+		return "table"
+	case *StringNode:
+		return "string " + quoteString(n.Text)
+	case *BoolNode, *NumberNode, *DatetimeNode:
+		return nodeKind(n) + " " + n.String()
+	}
+	return nodeKind(node)
+}
+
+// push appends a key or an array index to the path of the current node.
+func (d *decode) push(elem string) {
+	d.pushKey(elem, elem, "")
+}
+
+// pushKey appends key to the path of the current node, and name, the key
+// of the struct field key matches, to its canonical path. field is the
+// name of that struct field, if any.
+func (d *decode) pushKey(key, name, field string) {
+	d.path = append(d.path, key)
+	d.canon = append(d.canon, name)
+	d.fields = append(d.fields, field)
+}
+
+// pop removes the last element from the path of the current node.
+func (d *decode) pop() {
+	d.path = d.path[:len(d.path)-1]
+	d.canon = d.canon[:len(d.canon)-1]
+	d.fields = d.fields[:len(d.fields)-1]
+}
+
+// A location is where the value at a canonical path was read from.
+type location struct {
+	path []string // path in the document
+	pos  Pos
+}
+
+// locate records pos as the location of the current node, unless it
+// already has one.
+func (d *decode) locate(pos Pos) {
+	key := pathString(d.canon)
+	if _, ok := d.locations[key]; ok {
+		return
+	}
+	if d.locations == nil {
+		d.locations = make(map[string]location)
+	}
+	d.locations[key] = location{append([]string(nil), d.path...), pos}
+}
+
+// pathString formats path as dotted keys followed by array indexes:
+// servers[1].port.
+func pathString(path []string) string {
+	b := []byte{}
+	for i, elem := range path {
+		if strings.HasPrefix(elem, "[") {
+			b = append(b, elem...)
+			continue
+		}
+		if i > 0 {
+			b = append(b, keyGroupSep)
+		}
+		b = append(b, keyText(elem)...)
+	}
+	return string(b)
 }
 
 func (d *decode) top(v reflect.Value, node *ListNode) {
+	if isDeferred(v.Type()) {
+		d.deferred(v)
+		return
+	}
 	for _, node := range node.Nodes {
+		d.path, d.canon, d.fields = d.path[:0], d.canon[:0], d.fields[:0]
 		switch node := node.(type) {
 		case *EntryGroupNode:
-			for _, key := range node.KeyGroup.StringKeys() {
-				var ok bool
-				v, ok = d.findField("keygroup", v, key)
-				if !ok {
-					return
-				}
-			}
-			for _, node := range node.Entries.Nodes {
-				d.entry(v, node.(*EntryNode))
-			}
+			d.group(v, node)
 		case *EntryNode:
 			d.entry(v, node)
 		}
 	}
 }
 
-func (d *decode) findField(context string, v reflect.Value, key string) (next reflect.Value, ok bool) {
-	// Check type of target: struct or map[string]T
+// group decodes the entries of g into the table it declares below v.
+func (d *decode) group(v reflect.Value, g *EntryGroupNode) {
+	defer d.skip(len(d.path))
+	d.keyGroup(v, d.paths[g], g)
+}
+
+// tablePaths returns the path of the table each entry group declares. It
+// holds the index of the current element of each array of tables on the
+// way: [[a]] [a.b] [[a]] [a.b] declare a[0], a[0].b, a[1] and a[1].b.
+func tablePaths(root *ListNode) map[*EntryGroupNode][]string {
+	paths := make(map[*EntryGroupNode][]string)
+	arrays := make(map[string]int) // lengths of the arrays of tables
+	for _, node := range root.Nodes {
+		g, ok := node.(*EntryGroupNode)
+		if !ok {
+			continue
+		}
+		path := []string{}
+		keys := g.KeyGroup.StringKeys()
+		for i, k := range keys {
+			path = append(path, k)
+			name := pathString(path)
+			if i == len(keys)-1 && g.KeyGroup.Array {
+				path = append(path, fmt.Sprintf("[%d]", arrays[name]))
+				arrays[name]++
+			} else if n, ok := arrays[name]; ok {
+				path = append(path, fmt.Sprintf("[%d]", n-1))
+			}
+		}
+		paths[g] = path
+	}
+	return paths
+}
+
+// isIndex reports whether the path element elem is an array index.
+func isIndex(elem string) bool {
+	return strings.HasPrefix(elem, "[")
+}
+
+// keyGroup decodes entries into the table at path below v. Tables held in
+// maps are decoded into a copy that is stored back afterwards.
+func (d *decode) keyGroup(v reflect.Value, keys []string, g *EntryGroupNode) {
+	if len(keys) > 0 && isIndex(keys[0]) && !isDeferred(v.Type()) {
+		var i int
+		fmt.Sscanf(keys[0], "[%d]", &i)
+		elem := d.element(v, i)
+		d.push(keys[0])
+		defer d.pop()
+		d.locate(g.Position())
+		d.keyGroup(elem, keys[1:], g)
+		return
+	}
+	v = d.indirect(v)
+	if isDeferred(v.Type()) {
+		d.deferred(v)
+		return
+	}
+	if len(keys) == 0 {
+		d.pos = g.Position()
+		if !isTableTarget(v) {
+			d.typeError(nil, v)
+		}
+		for _, node := range g.Entries.Nodes {
+			d.entry(v, node.(*EntryNode))
+		}
+		return
+	}
+	d.pos = g.Position()
+	defer d.ordered(v)()
+	next, ok := d.findField(v, keys[0])
+	name, field := d.use(v, keys[0], g.Position())
+	d.pushKey(keys[0], name, field)
+	defer d.pop()
+	d.locate(g.Position())
+	if !ok {
+		d.unknown(v)
+		return
+	}
+	d.keyGroup(next, keys[1:], g)
+	d.store(v, keys[0], next)
+}
+
+// element returns element i of the array of tables v, appending it if i
+// is the length of v.
+func (d *decode) element(v reflect.Value, i int) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		s, ok := v.Interface().([]interface{})
+		if v.NumMethod() != 0 || !ok && !v.IsNil() {
+			d.typeError(newArray(d.pos, newList(d.pos)), v)
+		}
+		if i == len(s) {
+			s = append(s, nil)
+			v.Set(reflect.ValueOf(s))
+		}
+		return reflect.ValueOf(s).Index(i)
+	case reflect.Slice:
+		if i == v.Len() {
+			v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+			d.defaults(v.Index(i))
+		}
+		return v.Index(i)
+	case reflect.Array:
+		if i >= v.Len() {
+			d.failf(ErrTypeMismatch, "array of %d elements does not fit in %s", i+1, v.Type())
+		}
+		return v.Index(i)
+	}
+	d.typeError(newArray(d.pos, newList(d.pos)), v)
+	return v
+}
+
+// isTableTarget reports whether tables can be decoded into v.
+func isTableTarget(v reflect.Value) bool {
+	switch {
+	case v.Type() == orderedMapType, v.Kind() == reflect.Struct:
+		return true
+	case v.Kind() == reflect.Map:
+		return isMapKey(v.Type().Key())
+	}
+	return false
+}
+
+// unknown reports the current key, which has no matching field in table
+// v, when decoding strictly.
+func (d *decode) unknown(v reflect.Value) {
+	if d.strict {
+		line, col := d.position(d.pos)
+		d.report(&UnknownKeyError{
+			Path:        append([]string(nil), d.path...),
+			Line:        line,
+			Column:      col,
+			Suggestions: suggest(typeFields(v.Type(), d.naming), d.path[len(d.path)-1]),
+		})
+	}
+}
+
+// required reports the fields tagged required that the document does not
+// set, in v and the values it holds. Structs are checked only for the
+// tables the document declares.
+func (d *decode) required(v reflect.Value) {
 	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			d.required(v.Elem())
+		}
+	case reflect.Struct:
+		table, ok := d.locations[pathString(d.canon)]
+		if !ok && len(d.canon) > 0 {
+			return
+		}
+		line := 0
+		if ok {
+			line = d.line(table.pos)
+		}
+		path := append([]string{}, table.path...)
+		for _, f := range typeFields(v.Type(), d.naming) {
+			d.pushKey(f.name, f.name, v.Type().Field(f.index).Name)
+			if _, ok := d.locations[pathString(d.canon)]; f.required && !ok {
+				d.report(&MissingKeyError{
+					Path:  path,
+					Key:   f.name,
+					Field: d.fieldPath(),
+					Line:  line,
+				})
+			}
+			d.required(v.Field(f.index))
+			d.pop()
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			d.push(fmt.Sprintf("[%d]", i))
+			d.required(v.Index(i))
+			d.pop()
+		}
 	case reflect.Map:
+		e := &encode{}
+		for _, k := range v.MapKeys() {
+			d.push(e.mapKey(k))
+			d.required(v.MapIndex(k))
+			d.pop()
+		}
+	}
+}
+
+// newTable returns a new table for a generic target.
+func (d *decode) newTable() reflect.Value {
+	if d.generic.Table != nil && d.generic.Table != genericMapType {
+		return reflect.ValueOf(NewOrderedMap())
+	}
+	return reflect.ValueOf(make(map[string]interface{}))
+}
+
+// ordered makes the generic tables decoded below v OrderedMaps if v is an
+// OrderedMap, until the returned function is called.
+func (d *decode) ordered(v reflect.Value) func() {
+	table := d.generic.Table
+	if v.Type() == orderedMapType {
+		d.generic.Table = reflect.PtrTo(orderedMapType)
+	}
+	return func() { d.generic.Table = table }
+}
+
+// indirect returns the table v stands for, following pointers and
+// interface{} values and allocating nil ones.
+func (d *decode) indirect(v reflect.Value) reflect.Value {
+	for {
+		switch {
+		case v.Kind() == reflect.Interface && v.NumMethod() == 0:
+			if v.IsNil() {
+				v.Set(d.newTable())
+			}
+		case v.Kind() == reflect.Ptr:
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+				d.defaults(v.Elem())
+			}
+		default:
+			return v
+		}
+		v = v.Elem()
+	}
+}
+
+// findField returns the value to decode key of table v into. For a map or
+// an OrderedMap it is a new value holding the current element, which
+// store writes back.
+func (d *decode) findField(v reflect.Value, key string) (next reflect.Value, ok bool) {
+	// Check type of target: struct, OrderedMap or map[K]T
+	switch {
+	case v.Type() == orderedMapType:
+		next = reflect.New(emptyInterfaceType).Elem()
+		if e, ok := v.Addr().Interface().(*OrderedMap).Get(key); ok {
+			next.Set(reflect.ValueOf(e))
+		}
+		return next, true
+	case v.Kind() == reflect.Map:
 		t := v.Type()
-		if t.Key().Kind() != reflect.String {
-			d.error(&UnmarshalTypeError{context, v.Type()})
+		if !isMapKey(t.Key()) {
+			d.typeError(nil, v)
 		}
 		// init map
 		if v.IsNil() {
 			v.Set(reflect.MakeMap(v.Type()))
 		}
-	case reflect.Struct:
+		next = reflect.New(t.Elem()).Elem()
+		if e := v.MapIndex(d.mapKey(t.Key(), key)); e.IsValid() {
+			next.Set(e)
+		} else {
+			d.defaults(next)
+		}
+		return next, true
+	case v.Kind() == reflect.Struct:
 		// continue.
 	default:
-		d.error(&UnmarshalTypeError{context, v.Type()})
+		d.typeError(nil, v)
 	}
 
-	// Map. for entry only.
-	if v.Kind() == reflect.Map {
-		if context == "keygroup" {
-			next = reflect.ValueOf(make(map[string]interface{}))
-			v.SetMapIndex(reflect.ValueOf(key), next)
-			return next, true
-		} else { // entry
-			next = reflect.New(v.Type().Elem()).Elem()
-			return next, true
+	// Struct
+	if f, _, ok := d.field(v.Type(), key); ok {
+		return v.Field(f.index), true
+	}
+	// can't find the field
+	return reflect.ValueOf(nil), false
+}
+
+// field returns the field of struct type t holding key, and the name of
+// the field key matches.
+func (d *decode) field(t reflect.Type, key string) (field, string, bool) {
+	for _, f := range typeFields(t, d.naming) {
+		if name, ok := f.match(key, d.caseSensitive); ok {
+			return f, name, true
 		}
 	}
+	return field{}, "", false
+}
 
-	// Struct
-	t := v.Type()
-	for i := 0; i < t.NumField(); i ++ {
-		tf := t.Field(i)
-		name := tf.Tag.Get("toml")
-		if name == "" {
-			name = tf.Name
+// use records the use of key at pos in table v and returns its canonical
+// name, the key of the struct field it matches or key itself, and the
+// name of that field. It warns about deprecated keys and aliases, and
+// fails when a key and its alias are both set.
+func (d *decode) use(v reflect.Value, key string, pos Pos) (string, string) {
+	if v.Kind() != reflect.Struct {
+		return key, ""
+	}
+	f, name, ok := d.field(v.Type(), key)
+	if !ok {
+		return key, ""
+	}
+	goName := v.Type().Field(f.index).Name
+	path := pathString(append(d.path[:len(d.path):len(d.path)], key))
+	switch {
+	case name != f.name:
+		d.warn(path, pos, fmt.Sprintf("%s is deprecated, use %s", key, f.name))
+	case f.deprecated:
+		d.warn(path, pos, fmt.Sprintf("%s is deprecated", key))
+	}
+	if len(f.aliases) == 0 {
+		return f.name, goName
+	}
+	table := pathString(d.canon)
+	k := table + "\x00" + f.name
+	if prev, ok := d.names[k]; ok && !strings.EqualFold(prev.key, key) {
+		d.error(newError(ErrDuplicateKey, "toml: %s (line %d): %s and %s (line %d) are both set", path, d.line(pos), key, prev.key, d.line(prev.pos)))
+	}
+	if d.names == nil {
+		d.names = make(map[string]keyUse)
+	}
+	d.names[k] = keyUse{key, pos}
+	return f.name, goName
+}
+
+// A keyUse is the key setting a field and where.
+type keyUse struct {
+	key string
+	pos Pos
+}
+
+// warn reports a warning about the key at path and pos.
+func (d *decode) warn(path string, pos Pos, msg string) {
+	if d.warning != nil {
+		d.warning(Warning{Path: path, Line: d.line(pos), Message: msg})
+	}
+}
+
+// line returns the line number of pos in the document.
+func (d *decode) line(pos Pos) int {
+	line, _ := d.position(pos)
+	return line
+}
+
+// position returns the line and column of pos in the document, or zeros
+// if the document is unknown.
+func (d *decode) position(pos Pos) (line, col int) {
+	if d.tree == nil || pos < 0 || int(pos) > len(d.tree.text) {
+		return 0, 0
+	}
+	text := d.tree.text[:pos]
+	return 1 + strings.Count(text, "\n"), len(text) - strings.LastIndex(text, "\n")
+}
+
+// store writes the value decoded for key back into table v. Struct
+// fields are decoded in place.
+func (d *decode) store(v reflect.Value, key string, next reflect.Value) {
+	switch {
+	case v.Type() == orderedMapType:
+		v.Addr().Interface().(*OrderedMap).Set(key, next.Interface())
+	case v.Kind() == reflect.Map:
+		v.SetMapIndex(d.mapKey(v.Type().Key(), key), next)
+	}
+}
+
+// isMapKey reports whether tables can be decoded into maps with keys of
+// type t: strings, integers and types implementing
+// encoding.TextUnmarshaler.
+func isMapKey(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// mapKey returns key as a map key of type t.
+func (d *decode) mapKey(t reflect.Type, key string) reflect.Value {
+	k := reflect.New(t)
+	if u, ok := k.Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(key)); err != nil {
+			d.keyError(key, t, ": "+err.Error())
 		}
-		if name == key || strings.EqualFold(name, key) {
-			f := v.Field(i)
-			if !f.CanSet() {
-				continue
-			}
-			return f, true
+		return k.Elem()
+	}
+	var err error
+	switch t.Kind() {
+	case reflect.String:
+		k.Elem().SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(key, 10, t.Bits()); err == nil {
+			k.Elem().SetInt(i)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		if u, err = strconv.ParseUint(key, 10, t.Bits()); err == nil {
+			k.Elem().SetUint(u)
 		}
 	}
-	// can't find the field
-	return reflect.ValueOf(nil), false
+	if err != nil {
+		d.keyError(key, t, "")
+	}
+	return k.Elem()
+}
+
+// keyError aborts the decoding of key of the current table, which does
+// not fit in a map key of type t, with detail appended to the message.
+func (d *decode) keyError(key string, t reflect.Type, detail string) {
+	path := pathString(append(d.path[:len(d.path):len(d.path)], key))
+	d.report(newError(ErrTypeMismatch, "toml: %scannot decode key %q into %s%s", where(path, d.line(d.pos)), key, t, detail))
+	panic(skipValue{})
 }
 
 func (d *decode) entry(v reflect.Value, node *EntryNode) {
+	defer d.skip(len(d.path))
 	key := node.Key.Key
-	f, ok := d.findField("entry", v, key)
+	v = d.indirect(v)
+	defer d.ordered(v)()
+	d.pos = node.Position()
+	f, ok := d.findField(v, key)
+	name, field := d.use(v, key, node.Position())
+	d.pushKey(key, name, field)
+	defer d.pop()
+	d.locate(node.Position())
 	if !ok {
+		d.unknown(v)
 		return
 	}
-	d.value(f, node.Value)
-	// Write to map, if using struct, f points into struct already.
-	if v.Kind() == reflect.Map {
-		v.SetMapIndex(reflect.ValueOf(key), f)
+	d.source = ""
+	if d.tree != nil && node.End > node.Value.Position() {
+		d.source = d.tree.text[node.Value.Position():node.End]
 	}
+	d.value(f, node.Value)
+	d.store(v, key, f)
 }
 
 func (d *decode) value(v reflect.Value, node Node) {
+	d.pos = node.Position()
+	if len(d.pathHooks) > 0 {
+		for _, h := range d.pathHooks[pathString(d.path)] {
+			if d.hook(h, v, node) {
+				return
+			}
+		}
+	}
+	for _, h := range d.hooks {
+		if d.hook(h, v, node) {
+			return
+		}
+	}
+	if d.convert(v, node) {
+		return
+	}
+
+	// Decode into the value a pointer points to, allocating it if needed.
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+			d.defaults(v.Elem())
+		}
+		v = v.Elem()
+		if d.convert(v, node) {
+			return
+		}
+	}
+
+	if u := unionOf(v.Type()); u != nil {
+		n, ok := node.(*InlineTableNode)
+		if !ok {
+			d.typeError(node, v)
+		}
+		d.union(v, u, n)
+		return
+	}
+	if v.Type() == primitiveType || v.Type() == rawMessageType {
+		d.primitive(v, node)
+		return
+	}
+
 	switch n := node.(type) {
 	case *BoolNode:
 		value := n.True
@@ -157,93 +1091,271 @@ func (d *decode) value(v reflect.Value, node Node) {
 			if v.NumMethod() == 0 {
 				v.Set(reflect.ValueOf(value))
 			} else {
-				d.error(&UnmarshalTypeError{"bool", v.Type()})
+				d.typeError(n, v)
 			}
 		default:
-			d.error(&UnmarshalTypeError{"bool", v.Type()})
+			d.typeError(n, v)
 		}
 	case *StringNode:
 		value := n.Text
 		switch v.Kind() {
+		case reflect.Int64:
+			if v.Type() != durationType {
+				d.typeError(n, v)
+			}
+			dur, err := time.ParseDuration(value)
+			if err != nil {
+				d.failf(ErrInvalidValue, "%v", err)
+			}
+			v.SetInt(int64(dur))
 		case reflect.String:
 			v.SetString(value)
 		case reflect.Interface:
 			if v.NumMethod() == 0 {
 				v.Set(reflect.ValueOf(value))
 			} else {
-				d.error(&UnmarshalTypeError{"string", v.Type()})
+				d.typeError(n, v)
 			}
 		default:
-			d.error(&UnmarshalTypeError{"string", v.Type()})
+			d.typeError(n, v)
 		}
 	case *NumberNode:
+		if d.bigNumber(v, n) {
+			return
+		}
 		switch v.Kind() {
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			if !n.IsInt {
-				d.error(&UnmarshalTypeError{"int", v.Type()})
+				d.typeError(n, v)
+			}
+			if n.Overflow || v.OverflowInt(n.Int) {
+				d.overflow(n, v)
 			}
 			v.SetInt(n.Int)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if !n.IsInt {
+				d.typeError(n, v)
+			}
+			u, err := uint64(n.Int), error(nil)
+			if n.Overflow {
+				u, err = strconv.ParseUint(strings.TrimPrefix(n.Text, "+"), 10, 64)
+			}
+			if err != nil || n.Int < 0 || v.OverflowUint(u) {
+				d.overflow(n, v)
+			}
+			v.SetUint(u)
 		case reflect.Float32, reflect.Float64:
 			if !n.IsFloat {
-				d.error(&UnmarshalTypeError{"float", v.Type()})
+				d.typeError(n, v)
+			}
+			if n.Overflow || v.OverflowFloat(n.Float) {
+				d.overflow(n, v)
 			}
 			v.SetFloat(n.Float)
 		case reflect.Interface:
 			if v.NumMethod() == 0 {
+				t := d.generic.Float
 				if n.IsInt {
+					t = d.generic.Integer
+				}
+				switch {
+				case t == numberType:
+					v.Set(reflect.ValueOf(Number(n.Text)))
+				case n.Overflow:
+					d.overflow(n, v)
+				case t == nil && n.IsInt:
 					v.Set(reflect.ValueOf(n.Int))
 					//pd("int %s %p", v, v)
-				} else {
+				case t == nil:
 					v.Set(reflect.ValueOf(n.Float))
+				default:
+					newv := reflect.New(t).Elem()
+					if n.IsInt && isFloatKind(t.Kind()) {
+						newv.SetFloat(float64(n.Int))
+					} else {
+						d.value(newv, n)
+					}
+					v.Set(newv)
 				}
 			} else {
-				d.error(&UnmarshalTypeError{"number", v.Type()})
+				d.typeError(n, v)
 			}
 		default:
-			d.error(&UnmarshalTypeError{"number", v.Type()})
+			d.typeError(n, v)
 		}
 	case *DatetimeNode:
 		value := reflect.ValueOf(n.Time)
 		switch k := v.Kind(); {
 		case k == reflect.Struct && v.Type() == timeType:
 			v.Set(value)
+		case v.Type() == localDateType && n.Kind == LocalDate:
+			v.Set(reflect.ValueOf(dateOf(n.Time)))
+		case v.Type() == localTimeType && n.Kind == LocalTime:
+			v.Set(reflect.ValueOf(timeOfDayOf(n.Time)))
+		case v.Type() == localDateTimeType && n.Kind == LocalDatetime:
+			v.Set(reflect.ValueOf(dateTimeOf(n.Time)))
 		case k == reflect.Interface:
 			if v.NumMethod() == 0 {
+				if d.generic.LocalTypes && n.Kind != OffsetDatetime {
+					value = localValue(n)
+				}
 				v.Set(value)
 			} else {
-				d.error(&UnmarshalTypeError{"datetime", v.Type()})
+				d.typeError(n, v)
 			}
 		default:
-			d.error(&UnmarshalTypeError{"datetime", v.Type()})
+			d.typeError(n, v)
 		}
 	case *ArrayNode:
 		switch v.Kind() {
 		case reflect.Interface:
-			l := len(n.Array.Nodes)
 			if v.NumMethod() == 0 {
-				newv := reflect.ValueOf(make([]interface{}, l))
+				newv := reflect.New(reflect.TypeOf([]interface{}{})).Elem()
+				newv.Set(reflect.MakeSlice(newv.Type(), 0, len(n.Array.Nodes)))
 				d.value(newv, n)
 				v.Set(newv)
 			} else {
-				d.error(&UnmarshalTypeError{"array", v.Type()})
+				d.typeError(n, v)
 			}
 		case reflect.Array, reflect.Slice:
 			l := len(n.Array.Nodes)
-			if v.Len() < l {
-				if v.Kind() == reflect.Array { 
-					d.errorf("run out of fixed array, len is %d, want %d -- %s", v.Len(), l, n)
-				}
+			switch {
+			case v.Kind() == reflect.Array && v.Len() < l:
+				d.failf(ErrTypeMismatch, "array of %d elements does not fit in %s", l, v.Type())
+			case v.Kind() == reflect.Slice && v.Cap() < l:
 				// Growing slice
-				newv := reflect.MakeSlice(v.Type(), l, l)
-				reflect.Copy(newv, v)
-				v.Set(newv)
+				v.Set(reflect.MakeSlice(v.Type(), l, l))
+			case v.Kind() == reflect.Slice:
 				v.SetLen(l)
 			}
+			zero := reflect.Zero(v.Type().Elem())
+			for i := 0; i < v.Len(); i++ {
+				v.Index(i).Set(zero)
+				d.defaults(v.Index(i))
+			}
 			for i, subn := range n.Array.Nodes {
-				d.value(v.Index(i), subn)
+				d.arrayValue(v.Index(i), i, subn)
+			}
+		default:
+			d.typeError(n, v)
+		}
+	case *InlineTableNode:
+		switch v.Kind() {
+		case reflect.Interface:
+			if v.NumMethod() == 0 {
+				newv := reflect.New(emptyInterfaceType).Elem()
+				newv.Set(d.newTable())
+				d.inlineTable(newv, n)
+				v.Set(newv)
+			} else {
+				d.typeError(n, v)
 			}
+		case reflect.Map, reflect.Struct:
+			d.inlineTable(v, n)
 		default:
-			d.error(&UnmarshalTypeError{"array", v.Type()})
+			d.typeError(n, v)
+		}
+	}
+}
+
+// arrayValue decodes node, element i of an array, into v.
+func (d *decode) arrayValue(v reflect.Value, i int, node Node) {
+	defer d.skip(len(d.path))
+	d.push(fmt.Sprintf("[%d]", i))
+	d.locate(node.Position())
+	d.source = ""
+	d.value(v, node)
+	d.pop()
+}
+
+// bigNumber stores n in v if v is a Number or a math/big value, using the
+// exact text of n. It reports whether it did.
+func (d *decode) bigNumber(v reflect.Value, n *NumberNode) bool {
+	var ok bool
+	switch v.Type() {
+	case numberType:
+		v.SetString(n.Text)
+		return true
+	case bigIntType:
+		if !n.IsInt {
+			d.typeError(n, v)
+		}
+		_, ok = v.Addr().Interface().(*big.Int).SetString(n.Text, 10)
+	case bigFloatType:
+		f := v.Addr().Interface().(*big.Float)
+		if f.Prec() == 0 {
+			// Enough bits for every digit of the literal.
+			f.SetPrec(uint(len(n.Text)) * 4)
 		}
+		_, ok = f.SetString(n.Text)
+	case bigRatType:
+		_, ok = v.Addr().Interface().(*big.Rat).SetString(n.Text)
+	default:
+		return false
+	}
+	if !ok {
+		d.failf(ErrInvalidValue, "cannot decode %s into %s", n.Text, v.Type())
+	}
+	return true
+}
+
+// hook runs h for node and v and reports whether it stored the value.
+func (d *decode) hook(h DecodeHook, v reflect.Value, node Node) bool {
+	ok, err := h(node, v)
+	if err != nil {
+		// The error unwraps to err, so that errors.Is still finds it.
+		d.failf(err, "%v", err)
+	}
+	return ok
+}
+
+// convert stores node in v with the ConvertFunc added for their types, if
+// there is one, and reports whether it did.
+func (d *decode) convert(v reflect.Value, node Node) bool {
+	f, ok := d.converters[convertKey{node.Type(), v.Type()}]
+	if !ok {
+		return false
+	}
+	x, err := f(node)
+	if err != nil {
+		d.failf(ErrInvalidValue, "%v", err)
+	}
+	xv := reflect.ValueOf(x)
+	switch {
+	case x == nil:
+		v.Set(reflect.Zero(v.Type()))
+	case xv.Type().AssignableTo(v.Type()):
+		v.Set(xv)
+	case isIntKind(xv.Kind()) && v.Kind() == reflect.String:
+		// A conversion would give the string of the rune the integer encodes.
+		d.typeError(node, v)
+	case xv.Type().ConvertibleTo(v.Type()):
+		v.Set(xv.Convert(v.Type()))
+	default:
+		d.failf(nil, "conversion to %s returned %s", v.Type(), xv.Type())
+	}
+	return true
+}
+
+// localValue returns the Date, TimeOfDay or DateTime value of a
+// local datetime node.
+func localValue(n *DatetimeNode) reflect.Value {
+	switch n.Kind {
+	case LocalDate:
+		return reflect.ValueOf(dateOf(n.Time))
+	case LocalTime:
+		return reflect.ValueOf(timeOfDayOf(n.Time))
+	}
+	return reflect.ValueOf(dateTimeOf(n.Time))
+}
+
+// overflow aborts the decoding of a number n too large for v.
+func (d *decode) overflow(n *NumberNode, v reflect.Value) {
+	d.failf(ErrIntegerOverflow, "%s %s overflows %s", nodeKind(n), n.Text, v.Type())
+}
+
+func (d *decode) inlineTable(v reflect.Value, n *InlineTableNode) {
+	for _, node := range n.Entries.Nodes {
+		d.entry(v, node.(*EntryNode))
 	}
 }
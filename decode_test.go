@@ -1,7 +1,18 @@
 package toml
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 )
 
 var doc3 = `
@@ -15,7 +26,7 @@ type User struct {
 }
 
 type Rc struct {
-	A int
+	A    int
 	User User
 }
 
@@ -26,7 +37,930 @@ func TestDecode(t *testing.T) {
 	//rc = Rc{}
 	//rc = interface{}{}
 	//rc = make(map[string]interface{})
-	e := Unmarshal(doc3, &rc)
-	if e != nil { panic(e) }
+	e := Unmarshal([]byte(doc3), &rc)
+	if e != nil {
+		panic(e)
+	}
 	pd(rc)
 }
+
+func TestDecoderOptions(t *testing.T) {
+	doc := "a = 1\n[user]\nname = \"guten\"\nage = 3\n"
+
+	var rc Rc
+	dec := NewDecoder(strings.NewReader(doc))
+	dec.Strict()
+	if err := dec.Decode(&rc); err == nil || err.Error() != "toml: user.age (line 4): unknown key" {
+		t.Errorf("strict: %v", err)
+	}
+
+	rc = Rc{}
+	dec = NewDecoder(strings.NewReader(doc))
+	dec.CaseSensitive()
+	if err := dec.Decode(&rc); err != nil || rc.A != 0 || rc.User.Name != "" {
+		t.Errorf("case sensitive: %+v, %v", rc, err)
+	}
+
+	rc = Rc{}
+	dec = NewDecoder(strings.NewReader(doc))
+	dec.Hook(func(n Node, v reflect.Value) (bool, error) {
+		if s, ok := n.(*StringNode); ok && v.Kind() == reflect.String {
+			v.SetString(strings.ToUpper(s.Text))
+			return true, nil
+		}
+		return false, nil
+	})
+	if err := dec.Decode(&rc); err != nil || rc.A != 1 || rc.User.Name != "GUTEN" {
+		t.Errorf("hook: %+v, %v", rc, err)
+	}
+}
+
+func TestDecoderTypeHooks(t *testing.T) {
+	doc := "match = \"^a+$\"\nmode = 420\n[logging]\nlevel = \"warn\"\nfile = \"x.log\"\n"
+	var v struct {
+		Match   *regexp.Regexp
+		Mode    os.FileMode
+		Logging struct {
+			Level int
+			File  string
+		}
+	}
+	dec := NewDecoder(strings.NewReader(doc))
+	dec.HookType(NodeString, reflect.TypeOf((*regexp.Regexp)(nil)), func(n Node) (interface{}, error) {
+		return regexp.Compile(n.(*StringNode).Text)
+	})
+	dec.HookType(NodeNumber, reflect.TypeOf(os.FileMode(0)), func(n Node) (interface{}, error) {
+		return n.(*NumberNode).Int, nil
+	})
+	dec.HookPath("logging.level", func(n Node, v reflect.Value) (bool, error) {
+		v.SetInt(map[string]int64{"info": 1, "warn": 2}[n.(*StringNode).Text])
+		return true, nil
+	})
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Match.MatchString("aaa") || v.Mode != 0644 || v.Logging.Level != 2 || v.Logging.File != "x.log" {
+		t.Errorf("got %+v", v)
+	}
+
+	dec = NewDecoder(strings.NewReader("match = \"(\"\n"))
+	dec.HookType(NodeString, reflect.TypeOf((*regexp.Regexp)(nil)), func(n Node) (interface{}, error) {
+		return regexp.Compile(n.(*StringNode).Text)
+	})
+	if err := dec.Decode(&v); err == nil || !strings.HasPrefix(err.Error(), "toml: match (line 1): error parsing regexp") {
+		t.Errorf("conversion error: %v", err)
+	}
+
+	errLevel := errors.New("bad level")
+	dec = NewDecoder(strings.NewReader(doc))
+	dec.HookPath("logging.level", func(n Node, v reflect.Value) (bool, error) {
+		return false, errLevel
+	})
+	var logging struct{ Logging struct{ Level int } }
+	if err := dec.Decode(&logging); !errors.Is(err, errLevel) || err.Error() != "toml: logging.level (line 4): bad level" {
+		t.Errorf("hook error: %v", err)
+	}
+
+	var name struct{ Name string }
+	dec = NewDecoder(strings.NewReader("name = 65\n"))
+	dec.HookType(NodeNumber, reflect.TypeOf(""), func(n Node) (interface{}, error) {
+		return n.(*NumberNode).Int, nil
+	})
+	err := dec.Decode(&name)
+	if _, ok := err.(*UnmarshalTypeError); !ok || err.Error() != "toml: name (line 1): cannot decode integer 65 into string" {
+		t.Errorf("integer to string: %v, %q", err, name.Name)
+	}
+}
+
+func TestDecodeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.toml")
+	if err := os.WriteFile(path, []byte("a = \"x\"\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	var rc Rc
+	err := DecodeFile(path, &rc)
+	if err == nil || !strings.HasPrefix(err.Error(), path+": ") {
+		t.Errorf("DecodeFile error = %v", err)
+	}
+}
+
+func TestDecodeInlineTable(t *testing.T) {
+	var rc Rc
+	if err := Unmarshal([]byte("a = 1\nuser = {name = \"guten\"}\n"), &rc); err != nil || rc.User.Name != "guten" {
+		t.Errorf("struct: %+v, %v", rc, err)
+	}
+	var m map[string]interface{}
+	if err := Unmarshal([]byte("point = {x = 1, y = 2}\n"), &m); err != nil || !reflect.DeepEqual(m["point"], map[string]interface{}{"x": int64(1), "y": int64(2)}) {
+		t.Errorf("map: %v, %v", m, err)
+	}
+	m = nil
+	if err := Unmarshal([]byte("a = {}\n"), &m); err != nil || !reflect.DeepEqual(m["a"], map[string]interface{}{}) {
+		t.Errorf("empty: %#v, %v", m, err)
+	}
+	dec := NewDecoder(strings.NewReader("a = {}\n"))
+	dec.UseOrderedMap()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := v.(*OrderedMap).Get("a"); a == nil || a.(*OrderedMap).Len() != 0 {
+		t.Errorf("empty ordered: %#v", a)
+	}
+	if b, err := Marshal(v); err != nil || string(b) != "[a]\n" {
+		t.Errorf("empty ordered round trip: %q, %v", b, err)
+	}
+}
+
+func TestDecodeArrays(t *testing.T) {
+	doc := []byte(`
+matrix = [[1, 2], [3]]
+grid = [[1, 2], [3]]
+mixed = [1, "a", [2.5], {x = true}]
+short = [7]
+`)
+	var v struct {
+		Matrix [][]int
+		Grid   [2][3]int
+		Mixed  []interface{}
+		Short  []int
+	}
+	v.Grid[1][2] = 9
+	v.Short = []int{1, 2, 3}
+	if err := Unmarshal(doc, &v); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v.Matrix, [][]int{{1, 2}, {3}}) {
+		t.Errorf("Matrix = %v", v.Matrix)
+	}
+	if v.Grid != [2][3]int{{1, 2, 0}, {3, 0, 0}} {
+		t.Errorf("Grid = %v", v.Grid)
+	}
+	want := []interface{}{int64(1), "a", []interface{}{2.5}, map[string]interface{}{"x": true}}
+	if !reflect.DeepEqual(v.Mixed, want) {
+		t.Errorf("Mixed = %#v", v.Mixed)
+	}
+	if !reflect.DeepEqual(v.Short, []int{7}) {
+		t.Errorf("Short = %v", v.Short)
+	}
+
+	tests := []struct {
+		doc string
+		err string
+	}{
+		{"matrix = [[1, 2], [\"a\"]]\n", `toml: matrix[1][0] (line 1): cannot decode string "a" into int`},
+		{"grid = [[1], [2], [3]]\n", `toml: grid (line 1): array of 3 elements does not fit in [2][3]int`},
+		{"[mixed]\nx = 1\n", `toml: mixed (line 1): cannot decode table into []interface {}`},
+	}
+	for _, tt := range tests {
+		if err := Unmarshal([]byte(tt.doc), &v); err == nil || err.Error() != tt.err {
+			t.Errorf("%q: got error %v, want %q", tt.doc, err, tt.err)
+		}
+	}
+
+	dec := NewDecoder(strings.NewReader("mixed = [1, \"a\"]\n"))
+	dec.Dialect(Dialect{HomogeneousArrays: true})
+	if err := dec.Decode(&v); err == nil {
+		t.Errorf("mixed array decoded with HomogeneousArrays")
+	}
+
+	var m map[string]interface{}
+	if err := Unmarshal([]byte("b = []\n"), &m); err != nil || !reflect.DeepEqual(m["b"], []interface{}{}) {
+		t.Errorf("empty: %#v, %v", m, err)
+	}
+}
+
+func TestDecodeUnsigned(t *testing.T) {
+	var v struct {
+		Port uint16
+		Max  uint64
+	}
+	if err := Unmarshal([]byte("port = +80\nmax = +18446744073709551615\n"), &v); err != nil || v.Port != 80 || v.Max != 1<<64-1 {
+		t.Errorf("got %+v, %v", v, err)
+	}
+	err := Unmarshal([]byte("port = -1\n"), &v)
+	if err == nil || err.Error() != "toml: port (line 1): integer -1 overflows uint16" {
+		t.Errorf("negative: %v", err)
+	}
+}
+
+func TestDecodeQuotedKeys(t *testing.T) {
+	doc := []byte(`[hosts."::1"]
+name = "local"
+
+[["fe80::1"]]
+name = "link"
+`)
+	var v struct {
+		Hosts map[netip.Addr]struct{ Name string }
+		Links []struct{ Name string } `toml:"fe80::1"`
+	}
+	if err := Unmarshal(doc, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Hosts[netip.MustParseAddr("::1")].Name != "local" || len(v.Links) != 1 || v.Links[0].Name != "link" {
+		t.Errorf("got %+v", v)
+	}
+	var addrs map[netip.Addr]string
+	if err := Unmarshal([]byte("\"10.0.0.1\" = \"a\"\n\"::1\" = \"b\"\n"), &addrs); err != nil {
+		t.Fatal(err)
+	}
+	if addrs[netip.MustParseAddr("10.0.0.1")] != "a" || addrs[netip.IPv6Loopback()] != "b" {
+		t.Errorf("got %v", addrs)
+	}
+	err := Unmarshal([]byte(`"a\nb = 1`), &addrs)
+	if err == nil || !errors.Is(err, ErrSyntax) {
+		t.Errorf("unterminated key: %v", err)
+	}
+}
+
+func TestDecodeBigNumbers(t *testing.T) {
+	doc := []byte(`
+id = 123456789012345678901234567890
+price = 19.99
+ratio = 0.1
+count = 42
+small = 300
+`)
+	var v struct {
+		ID    *big.Int
+		Price big.Rat
+		Ratio *big.Float
+		Count Number
+		Small uint8
+	}
+	err := Unmarshal(doc, &v)
+	if err == nil || err.Error() != "toml: small (line 6): integer 300 overflows uint8" {
+		t.Errorf("small: %v", err)
+	}
+	if v.ID == nil || v.ID.String() != "123456789012345678901234567890" {
+		t.Errorf("ID = %v", v.ID)
+	}
+	if v.Price.String() != "1999/100" {
+		t.Errorf("Price = %v", v.Price.String())
+	}
+	if v.Ratio == nil || v.Ratio.Text('f', -1) != "0.1" {
+		t.Errorf("Ratio = %v", v.Ratio)
+	}
+	if i, err := v.Count.Int64(); err != nil || i != 42 {
+		t.Errorf("Count = %v, %v", v.Count, err)
+	}
+
+	var m interface{}
+	if err := Unmarshal(doc, &m); err == nil || err.Error() != "toml: id (line 2): integer 123456789012345678901234567890 overflows interface {}" {
+		t.Errorf("generic: %v", err)
+	}
+	dec := NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil || m.(map[string]interface{})["id"] != Number("123456789012345678901234567890") {
+		t.Errorf("UseNumber: %v, %v", m, err)
+	}
+}
+
+func TestDecodeNestedTables(t *testing.T) {
+	doc := []byte("[a]\nx = 1\n[a.b]\ny = 2\n[users.guten]\nname = \"g\"\n")
+	var m map[string]interface{}
+	if err := Unmarshal(doc, &m); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"a":     map[string]interface{}{"x": int64(1), "b": map[string]interface{}{"y": int64(2)}},
+		"users": map[string]interface{}{"guten": map[string]interface{}{"name": "g"}},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got %v, want %v", m, want)
+	}
+
+	var v struct {
+		Users map[string]*User
+	}
+	if err := Unmarshal(doc, &v); err != nil || v.Users["guten"].Name != "g" {
+		t.Errorf("map of structs: %v, %v", v, err)
+	}
+}
+
+func TestDecodeGenericTypes(t *testing.T) {
+	doc := "i = 3\nf = 1.5\nd = 1979-05-27\nt = 07:32:00.25\nl = 1979-05-27T07:32:00\no = 1979-05-27T07:32:00Z\n[tbl]\nx = 1\n"
+	var m interface{}
+	dec := NewDecoder(strings.NewReader(doc))
+	dec.GenericTypes(GenericTypes{
+		Integer:    reflect.TypeOf(float64(0)),
+		Float:      reflect.TypeOf(float32(0)),
+		LocalTypes: true,
+		Table:      reflect.TypeOf(NewOrderedMap()),
+	})
+	if err := dec.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	om, ok := m.(*OrderedMap)
+	if !ok {
+		t.Fatalf("got %T, want *OrderedMap", m)
+	}
+	want := map[string]interface{}{
+		"i": float64(3),
+		"f": float32(1.5),
+		"d": Date{1979, 5, 27},
+		"t": TimeOfDay{7, 32, 0, 250000000},
+		"l": DateTime{Date{1979, 5, 27}, TimeOfDay{7, 32, 0, 0}},
+	}
+	for k, w := range want {
+		if v, _ := om.Get(k); v != w {
+			t.Errorf("%s = %#v, want %#v", k, v, w)
+		}
+	}
+	if o, _ := om.Get("o"); reflect.TypeOf(o) != timeType {
+		t.Errorf("o = %T, want time.Time", o)
+	}
+	if tbl, _ := om.Get("tbl"); reflect.TypeOf(tbl) != reflect.TypeOf(om) {
+		t.Errorf("tbl = %T, want *OrderedMap", tbl)
+	}
+
+	dec = NewDecoder(strings.NewReader(doc))
+	dec.GenericTypes(GenericTypes{Float: reflect.TypeOf(0)})
+	if err := dec.Decode(&m); err == nil || err.Error() != "toml: cannot decode generic floats into int" {
+		t.Errorf("bad Float type: %v", err)
+	}
+
+	var v struct {
+		D Date
+		T TimeOfDay
+		L DateTime
+	}
+	if err := Unmarshal([]byte(doc), &v); err != nil || v.D.String() != "1979-05-27" || v.T.String() != "07:32:00.25" || v.L.String() != "1979-05-27T07:32:00" {
+		t.Errorf("local fields: %+v, %v", v, err)
+	}
+	if err := Unmarshal([]byte("D = 07:32:00\n"), &v); err == nil {
+		t.Errorf("time of day decoded into Date")
+	}
+	out, err := Marshal(v)
+	if err != nil || !strings.Contains(string(out), "L = 1979-05-27T07:32:00\n") {
+		t.Errorf("Marshal: %s, %v", out, err)
+	}
+}
+
+func TestDecodePrimitive(t *testing.T) {
+	doc := `name = "app"
+limit = [1, 2]
+
+[plugins.cache]
+size = 10
+
+[plugins.cache.backend]
+kind = "redis"
+
+[plugins.auth]
+realm = "x"
+`
+	var v struct {
+		Name    string
+		Limit   Primitive
+		Plugins map[string]Primitive
+	}
+	if err := Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Limit.Source != "[1, 2]" {
+		t.Errorf("Limit.Source = %q", v.Limit.Source)
+	}
+	var limit []int
+	if err := PrimitiveDecode(v.Limit, &limit); err != nil || !reflect.DeepEqual(limit, []int{1, 2}) {
+		t.Errorf("PrimitiveDecode(Limit) = %v, %v", limit, err)
+	}
+
+	cache := v.Plugins["cache"]
+	if want := "[plugins.cache]\nsize = 10\n\n[plugins.cache.backend]\nkind = \"redis\"\n\n"; cache.Source != want {
+		t.Errorf("cache.Source = %q, want %q", cache.Source, want)
+	}
+	var c struct {
+		Size    int
+		Backend struct{ Kind string }
+	}
+	if err := PrimitiveDecode(cache, &c); err != nil || c.Size != 10 || c.Backend.Kind != "redis" {
+		t.Errorf("PrimitiveDecode(cache) = %+v, %v", c, err)
+	}
+	var bad struct{ Size string }
+	err := PrimitiveDecode(cache, &bad)
+	if err == nil || err.Error() != `toml: plugins.cache.size (line 5): cannot decode integer 10 into string` {
+		t.Errorf("PrimitiveDecode error: %v", err)
+	}
+
+	var raw struct {
+		Plugins map[string]json.RawMessage
+		Limit   json.RawMessage
+	}
+	if err := Unmarshal([]byte(doc), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(raw.Plugins["cache"]); got != `{"backend":{"kind":"redis"},"size":10}` {
+		t.Errorf("RawMessage = %s", got)
+	}
+	if got := string(raw.Limit); got != `[1,2]` {
+		t.Errorf("RawMessage = %s", got)
+	}
+
+	var whole Primitive
+	if err := Unmarshal([]byte(doc), &whole); err != nil || whole.Source != doc {
+		t.Errorf("whole document: %q, %v", whole.Source, err)
+	}
+}
+
+func TestDecodeArrayOfTables(t *testing.T) {
+	doc := []byte(`[[servers]]
+name = "a"
+[servers.tls]
+on = true
+
+[[servers]]
+name = "b"
+
+[[servers.ports]]
+n = 80
+[[servers.ports]]
+n = 443
+`)
+	var m map[string]interface{}
+	if err := Unmarshal(doc, &m); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"servers": []interface{}{
+		map[string]interface{}{"name": "a", "tls": map[string]interface{}{"on": true}},
+		map[string]interface{}{"name": "b", "ports": []interface{}{
+			map[string]interface{}{"n": int64(80)},
+			map[string]interface{}{"n": int64(443)},
+		}},
+	}}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got %v, want %v", m, want)
+	}
+
+	var v struct {
+		Servers []struct {
+			Name  string
+			TLS   struct{ On bool }
+			Ports []struct{ N int }
+		}
+	}
+	if err := Unmarshal(doc, &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Servers) != 2 || !v.Servers[0].TLS.On || v.Servers[1].Name != "b" || len(v.Servers[1].Ports) != 2 || v.Servers[1].Ports[1].N != 443 {
+		t.Errorf("got %+v", v)
+	}
+
+	var p struct{ Servers Primitive }
+	if err := Unmarshal(doc, &p); err != nil {
+		t.Fatal(err)
+	}
+	var back []map[string]interface{}
+	if err := PrimitiveDecode(p.Servers, &back); err != nil || len(back) != 2 || back[1]["name"] != "b" {
+		t.Errorf("PrimitiveDecode = %v, %v", back, err)
+	}
+
+	var bad struct{ Servers string }
+	if err := Unmarshal(doc, &bad); err == nil || err.Error() != "toml: servers (line 1): cannot decode array into string" {
+		t.Errorf("array of tables into string: %v", err)
+	}
+}
+
+type step interface {
+	run() string
+}
+
+type httpStep struct {
+	URL     string
+	Retries int
+}
+
+func (s *httpStep) run() string { return "GET " + s.URL }
+
+type shellStep struct {
+	Type string
+	Cmd  string
+	Env  map[string]string
+}
+
+func (s shellStep) run() string { return s.Cmd }
+
+func init() {
+	RegisterUnion(reflect.TypeOf((*step)(nil)).Elem(), "type", map[string]reflect.Type{
+		"http":  reflect.TypeOf(&httpStep{}),
+		"shell": reflect.TypeOf(shellStep{}),
+	})
+}
+
+func TestDecodeUnion(t *testing.T) {
+	doc := []byte(`first = {type = "http", url = "x"}
+
+[[steps]]
+type = "http"
+url = "http://example.com"
+
+[[steps]]
+type = "shell"
+cmd = "make"
+[steps.env]
+GOOS = "linux"
+`)
+	var v struct {
+		First step
+		Steps []step
+	}
+	if err := Unmarshal(doc, &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Steps) != 2 || v.Steps[0].run() != "GET http://example.com" || v.First.run() != "GET x" {
+		t.Fatalf("got %+v", v)
+	}
+	if s, ok := v.Steps[1].(shellStep); !ok || s.Type != "shell" || s.Cmd != "make" || s.Env["GOOS"] != "linux" {
+		t.Errorf("Steps[1] = %#v", v.Steps[1])
+	}
+
+	b, err := Marshal(v)
+	want := `Steps = [{type = "http", URL = "http://example.com", Retries = 0}, {type = "shell", Cmd = "make", Env = {GOOS = "linux"}}]
+
+[First]
+type = "http"
+URL = "x"
+Retries = 0
+`
+	if err != nil || string(b) != want {
+		t.Errorf("Marshal = %s, %v, want %s", b, err, want)
+	}
+	var back struct {
+		First step
+		Steps []step
+	}
+	if err := Unmarshal(b, &back); err != nil || !reflect.DeepEqual(back, v) {
+		t.Errorf("round trip: got %+v, %v, want %+v", back, err, v)
+	}
+
+	errs := map[string]string{
+		"first = {url = \"x\"}\n":    "toml: first (line 1): missing type key selecting the toml.step",
+		"first = {type = \"ftp\"}\n": `toml: first (line 1): unknown type "ftp" for toml.step`,
+		"first = 1\n":                "toml: first (line 1): cannot decode integer 1 into toml.step",
+	}
+	for doc, want := range errs {
+		if err := Unmarshal([]byte(doc), &v); err == nil || err.Error() != want {
+			t.Errorf("%q: got error %v, want %q", doc, err, want)
+		}
+	}
+}
+
+func TestNaming(t *testing.T) {
+	names := []struct {
+		field, snake, kebab, camel string
+	}{
+		{"MaxConns", "max_conns", "max-conns", "maxConns"},
+		{"HTTPServer2", "http_server2", "http-server2", "httpServer2"},
+		{"UserID", "user_id", "user-id", "userID"},
+		{"URL", "url", "url", "url"},
+		{"Max_Conns", "max_conns", "max-conns", "maxConns"},
+	}
+	for _, n := range names {
+		if s, k, c := SnakeCase(n.field), KebabCase(n.field), CamelCase(n.field); s != n.snake || k != n.kebab || c != n.camel {
+			t.Errorf("%s: got %s %s %s, want %s %s %s", n.field, s, k, c, n.snake, n.kebab, n.camel)
+		}
+	}
+
+	type config struct {
+		MaxConns int
+		HTTPPort int
+		Name     string `toml:"title"`
+	}
+	doc := "max_conns = 10\nhttp_port = 80\ntitle = \"x\"\n"
+	var c config
+	dec := NewDecoder(strings.NewReader(doc))
+	dec.Naming(SnakeCase)
+	dec.Strict()
+	if err := dec.Decode(&c); err != nil || c != (config{10, 80, "x"}) {
+		t.Errorf("SnakeCase: %+v, %v", c, err)
+	}
+
+	var b bytes.Buffer
+	enc := NewEncoder(&b)
+	enc.Naming(KebabCase)
+	if err := enc.Encode(c); err != nil || b.String() != "max-conns = 10\nhttp-port = 80\ntitle = \"x\"\n" {
+		t.Errorf("KebabCase: %q, %v", b.String(), err)
+	}
+}
+
+func TestDecodeAliases(t *testing.T) {
+	type server struct {
+		ListenAddr string `toml:"listen_addr,alias=bind,alias=address"`
+		Workers    int    `toml:",deprecated"`
+	}
+	var v struct{ Server server }
+	var warnings []string
+	decode := func(doc string) error {
+		v = struct{ Server server }{}
+		warnings = nil
+		dec := NewDecoder(strings.NewReader(doc))
+		dec.Warn(func(w Warning) { warnings = append(warnings, w.String()) })
+		return dec.Decode(&v)
+	}
+
+	if err := decode("[server]\nbind = \":80\"\nworkers = 4\n"); err != nil || v.Server != (server{":80", 4}) {
+		t.Errorf("aliases: %+v, %v", v, err)
+	}
+	want := []string{"server.bind (line 2): bind is deprecated, use listen_addr", "server.workers (line 3): workers is deprecated"}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %q, want %q", warnings, want)
+	}
+
+	if err := decode("[server]\nlisten_addr = \":80\"\n"); err != nil || v.Server.ListenAddr != ":80" || len(warnings) != 0 {
+		t.Errorf("new name: %+v, %v, %q", v, err, warnings)
+	}
+
+	err := decode("[server]\naddress = \":81\"\nlisten_addr = \":80\"\n")
+	if err == nil || err.Error() != "toml: server.listen_addr (line 3): listen_addr and address (line 2) are both set" {
+		t.Errorf("both set: %v", err)
+	}
+}
+
+type defaultsLimits struct {
+	Burst int
+	Rate  float64 `default:"1.5"`
+}
+
+func (l *defaultsLimits) SetDefaults() {
+	l.Burst = 2 * int(l.Rate)
+}
+
+func TestDecodeDefaults(t *testing.T) {
+	type config struct {
+		Host    string        `default:"localhost"`
+		Port    int           `default:"8080"`
+		Version string        `default:"1.0"`
+		Ports   []int         `default:"[1, 2]"`
+		Timeout time.Duration `default:"30s"`
+		Debug   bool          `default:"true"`
+		Limits  defaultsLimits
+		Users   []struct {
+			Name string
+			Role string `default:"guest"`
+		}
+		Extra *defaultsLimits
+	}
+	doc := "port = 9000\ndebug = false\n[[users]]\nname = \"a\"\n[[users]]\nname = \"b\"\nrole = \"admin\"\n"
+	var c config
+	if err := Unmarshal([]byte(doc), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Host != "localhost" || c.Port != 9000 || c.Version != "1.0" || !reflect.DeepEqual(c.Ports, []int{1, 2}) || c.Timeout != 30*time.Second || c.Debug {
+		t.Errorf("got %+v", c)
+	}
+	if c.Limits != (defaultsLimits{2, 1.5}) || c.Extra != nil {
+		t.Errorf("Limits = %+v, Extra = %v", c.Limits, c.Extra)
+	}
+	if len(c.Users) != 2 || c.Users[0].Role != "guest" || c.Users[1].Role != "admin" {
+		t.Errorf("Users = %+v", c.Users)
+	}
+
+	c = config{Host: "example.com"}
+	if err := Unmarshal([]byte("[extra]\nrate = 4.0\n"), &c); err != nil || c.Host != "example.com" || c.Extra.Burst != 2 || c.Extra.Rate != 4 {
+		t.Errorf("preset: %+v, %+v, %v", c, c.Extra, err)
+	}
+
+	var bad struct {
+		N int `default:"x"`
+	}
+	if err := Unmarshal(nil, &bad); err == nil || err.Error() != `toml: N: cannot decode string "x" into int` {
+		t.Errorf("bad default: %v", err)
+	}
+	dec := NewDecoder(strings.NewReader("m = 1\n"))
+	dec.AllErrors()
+	var bad2 struct {
+		N int `default:"abc"`
+		M int
+		S struct {
+			P int `default:"[1]"`
+		}
+	}
+	err := dec.Decode(&bad2)
+	if err == nil || err.Error() != "toml: N: cannot decode string \"abc\" into int\ntoml: S.P: cannot decode array into int" || bad2.M != 1 {
+		t.Errorf("bad defaults, all errors: %v, %+v", err, bad2)
+	}
+}
+
+func TestDecodeTypeError(t *testing.T) {
+	doc := []byte("title = \"x\"\n\n[database]\n  port = \"5432\"\n")
+	var v struct {
+		Title    string
+		Database struct {
+			Port int
+		}
+	}
+	err := Unmarshal(doc, &v)
+	if err == nil || err.Error() != `toml: database.port (line 4): cannot decode string "5432" into int` {
+		t.Fatalf("got error %v", err)
+	}
+	var terr *UnmarshalTypeError
+	if !errors.As(err, &terr) {
+		t.Fatalf("error %T is not an *UnmarshalTypeError", err)
+	}
+	want := &UnmarshalTypeError{
+		Value:  `string "5432"`,
+		Type:   reflect.TypeOf(0),
+		Path:   []string{"database", "port"},
+		Field:  "Database.Port",
+		Line:   4,
+		Column: 10,
+	}
+	if !reflect.DeepEqual(terr, want) {
+		t.Errorf("got %+v, want %+v", terr, want)
+	}
+
+	var tables struct{ Database []int }
+	err = Unmarshal(doc, &tables)
+	if err == nil || err.Error() != "toml: database (line 3): cannot decode table into []int" {
+		t.Errorf("table: %v", err)
+	}
+}
+
+func TestDecodeAllErrors(t *testing.T) {
+	doc := `title = 1
+[server]
+port = "x"
+host = "h"
+extra = true
+[[backends]]
+name = 2
+[[backends]]
+name = "b"
+`
+	type config struct {
+		Title  string
+		Server struct {
+			Port int
+			Host string
+			Addr string `toml:"addr,required"`
+		}
+		Backends []struct {
+			Name   string
+			Weight int `toml:"weight,required"`
+		}
+		Cache *struct {
+			Size int `toml:"size,required"`
+		}
+	}
+	var v config
+	dec := NewDecoder(strings.NewReader(doc))
+	dec.Strict()
+	dec.AllErrors()
+	err := dec.Decode(&v)
+	want := []string{
+		`toml: title (line 1): cannot decode integer 1 into string`,
+		`toml: server.port (line 3): cannot decode string "x" into int`,
+		`toml: server.extra (line 5): unknown key`,
+		`toml: backends[0].name (line 7): cannot decode integer 2 into string`,
+		`toml: server (line 2): missing required key addr`,
+		`toml: backends[0] (line 6): missing required key weight`,
+		`toml: backends[1] (line 8): missing required key weight`,
+	}
+	var errs DecodeErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got error %v, want DecodeErrors", err)
+	}
+	if err.Error() != strings.Join(want, "\n") {
+		t.Errorf("got errors\n%v\nwant\n%s", err, strings.Join(want, "\n"))
+	}
+	if v.Server.Host != "h" || len(v.Backends) != 2 || v.Backends[1].Name != "b" {
+		t.Errorf("decoded %+v", v)
+	}
+
+	var unknown *UnknownKeyError
+	if !errors.As(err, &unknown) || !reflect.DeepEqual(unknown.Path, []string{"server", "extra"}) || unknown.Column != 1 {
+		t.Errorf("unknown key error %+v", unknown)
+	}
+	var missing *MissingKeyError
+	if !errors.As(err, &missing) || missing.Key != "addr" || missing.Field != "Server.Addr" {
+		t.Errorf("missing key error %+v", missing)
+	}
+
+	v = config{}
+	err = Unmarshal([]byte("[server]\nport = 1\n"), &v)
+	if err == nil || err.Error() != "toml: server (line 1): missing required key addr" {
+		t.Errorf("without AllErrors: %v", err)
+	}
+}
+
+func TestDecodeErrorKinds(t *testing.T) {
+	type server struct {
+		Port    uint8
+		Timeout time.Duration
+		Addr    string `toml:"addr,alias=bind,required"`
+		Level   string `validate:"oneof=debug info"`
+	}
+	tests := []struct {
+		doc  string
+		kind error
+	}{
+		{"port = \"x\"\naddr = \"a\"\n", ErrTypeMismatch},
+		{"port = 300\naddr = \"a\"\n", ErrIntegerOverflow},
+		{"timeout = \"soon\"\naddr = \"a\"\n", ErrInvalidValue},
+		{"port = 1\n", ErrMissingField},
+		{"addr = \"a\"\nbind = \"b\"\n", ErrDuplicateKey},
+		{"addr = \"a\"\naddr = \"b\"\n", ErrDuplicateKey},
+		{"addr = \"a\"\nlevel = \"trace\"\n", ErrInvalidValue},
+		{"addr = \"a\"\nhost = \"h\"\n", ErrUnknownField},
+		{"addr = 1979-02-30\n", ErrInvalidDatetime},
+	}
+	for _, tt := range tests {
+		var v server
+		dec := NewDecoder(strings.NewReader(tt.doc))
+		dec.Strict()
+		if err := dec.Decode(&v); !errors.Is(err, tt.kind) {
+			t.Errorf("%q: got error %v, want %v", tt.doc, err, tt.kind)
+		}
+	}
+
+	var v server
+	dec := NewDecoder(strings.NewReader("port = \"x\"\nhost = \"h\"\n"))
+	dec.Strict()
+	dec.AllErrors()
+	err := dec.Decode(&v)
+	for _, kind := range []error{ErrTypeMismatch, ErrUnknownField, ErrMissingField} {
+		if !errors.Is(err, kind) {
+			t.Errorf("all errors: %v is not %v", err, kind)
+		}
+	}
+	if errors.Is(err, ErrSyntax) {
+		t.Errorf("all errors: %v is %v", err, ErrSyntax)
+	}
+}
+
+func TestUnknownKeySuggestions(t *testing.T) {
+	var v struct {
+		Server struct {
+			ListenAddress string `toml:"listen_address"`
+			ListenPort    int    `toml:"listen_port"`
+			Port          int    `toml:"port"`
+			MaxConns      int    `toml:"max_conns"`
+		}
+	}
+	tests := []struct {
+		key         string
+		suggestions []string
+	}{
+		{"listen_adress", []string{"listen_address"}},
+		{"ListenAddress", []string{"listen_address"}},
+		{"max-conns", []string{"max_conns"}},
+		{"prot", []string{"port"}},
+		{"listen_prt", []string{"listen_port"}},
+		{"timeout", nil},
+	}
+	for _, tt := range tests {
+		dec := NewDecoder(strings.NewReader("[server]\n" + tt.key + " = 1\n"))
+		dec.Strict()
+		var uerr *UnknownKeyError
+		if err := dec.Decode(&v); !errors.As(err, &uerr) {
+			t.Errorf("%s: got error %v", tt.key, err)
+		} else if !reflect.DeepEqual(uerr.Suggestions, tt.suggestions) {
+			t.Errorf("%s: suggestions %q, want %q", tt.key, uerr.Suggestions, tt.suggestions)
+		}
+	}
+
+	dec := NewDecoder(strings.NewReader("[server]\nlisten_adress = \"x\"\n"))
+	dec.Strict()
+	err := dec.Decode(&v)
+	if err == nil || err.Error() != `toml: server.listen_adress (line 2): unknown key, did you mean "listen_address"?` {
+		t.Errorf("got error %v", err)
+	}
+	msg := (&UnknownKeyError{Path: []string{"a"}, Suggestions: []string{"b", "c", "d"}}).Error()
+	if msg != `toml: a: unknown key, did you mean "b", "c" or "d"?` {
+		t.Errorf("got message %s", msg)
+	}
+}
+
+func TestDecodeAllErrorsElements(t *testing.T) {
+	doc := `x = [1, "a", 3, "b"]
+t = {a = "p", b = 2, c = "q"}
+y = [{a = "s"}, {a = 1}, {a = "t"}]
+[m]
+k = 1
+2 = 2
+`
+	var v struct {
+		X []int
+		T struct{ A, B, C int }
+		Y []struct{ A int }
+		M map[int]int
+	}
+	dec := NewDecoder(strings.NewReader(doc))
+	dec.AllErrors()
+	err := dec.Decode(&v)
+	want := []string{
+		`toml: x[1] (line 1): cannot decode string "a" into int`,
+		`toml: x[3] (line 1): cannot decode string "b" into int`,
+		`toml: t.a (line 2): cannot decode string "p" into int`,
+		`toml: t.c (line 2): cannot decode string "q" into int`,
+		`toml: y[0].a (line 3): cannot decode string "s" into int`,
+		`toml: y[2].a (line 3): cannot decode string "t" into int`,
+		`toml: m.k (line 5): cannot decode key "k" into int`,
+	}
+	if err == nil || err.Error() != strings.Join(want, "\n") {
+		t.Errorf("got errors\n%v\nwant\n%s", err, strings.Join(want, "\n"))
+	}
+	if !reflect.DeepEqual(v.X, []int{1, 0, 3, 0}) || v.T.B != 2 || len(v.Y) != 3 || v.Y[1].A != 1 || v.M[2] != 2 {
+		t.Errorf("decoded %+v", v)
+	}
+}
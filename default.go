@@ -0,0 +1,70 @@
+package toml
+
+import (
+	"reflect"
+)
+
+// A Defaulter sets the default values of its fields. The decoder calls
+// SetDefaults on each struct it decodes a table into before decoding the
+// table, after applying the default struct tags.
+type Defaulter interface {
+	SetDefaults()
+}
+
+var defaulterType = reflect.TypeOf((*Defaulter)(nil)).Elem()
+
+// defaults sets the defaults of a new struct v and of the structs it
+// holds: fields with a `default:"value"` tag that are still zero get the
+// value, then SetDefaults is called. The value is TOML, [1, 2] for
+// example; one that does not parse is a string, so "30s" is a valid
+// default for a time.Duration. String fields take the tag text as is
+// unless it is a quoted TOML string.
+func (d *decode) defaults(v reflect.Value) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for _, f := range typeFields(v.Type(), d.naming) {
+		fv := v.Field(f.index)
+		tag, ok := v.Type().Field(f.index).Tag.Lookup("default")
+		switch {
+		case ok && fv.IsZero():
+			d.setDefault(fv, f.name, tag)
+		case fv.Kind() == reflect.Struct:
+			d.push(f.name)
+			d.defaults(fv)
+			d.pop()
+		}
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(defaulterType) {
+		v.Addr().Interface().(Defaulter).SetDefaults()
+	} else if v.Type().Implements(defaulterType) {
+		v.Interface().(Defaulter).SetDefaults()
+	}
+}
+
+// setDefault decodes the default tag of the field name into v. When all
+// errors are collected, a default that does not decode leaves v as it is.
+func (d *decode) setDefault(v reflect.Value, name, tag string) {
+	defer d.skip(len(d.path))
+	d.push(name)
+	defer d.pop()
+	// The node is not part of the document.
+	dd := *d
+	dd.tree, dd.locations = nil, nil
+	dd.value(v, d.defaultValue(v, tag))
+}
+
+// defaultValue returns the node of the default tag for field v.
+func (d *decode) defaultValue(v reflect.Value, tag string) Node {
+	for v.Kind() == reflect.Ptr {
+		v = reflect.New(v.Type().Elem()).Elem()
+	}
+	tree, err := Parse("default = " + tag + "\n")
+	if err == nil {
+		value := tree.Root.Nodes[0].(*EntryNode).Value
+		if _, ok := value.(*StringNode); ok || v.Kind() != reflect.String {
+			return value
+		}
+	}
+	return newString(0, tag, tag)
+}
@@ -0,0 +1,49 @@
+package toml
+
+import (
+	"fmt"
+)
+
+// A Dialect selects the syntax accepted by the parser and decoder. The
+// zero value is standard TOML 1.0; Version enables later versions and
+// each other field enables one extension supported by earlier versions
+// of this package.
+type Dialect struct {
+	// Version is the latest TOML version whose syntax is accepted.
+	Version Version
+
+	// ColonSeparator accepts "key: value" as well as "key = value".
+	ColonSeparator bool
+
+	// DashSeparators treats a line of dashes as a document separator: one
+	// before any key is skipped, one after a key ends the document.
+	DashSeparators bool
+
+	// HomogeneousArrays rejects arrays mixing values of different types,
+	// as TOML 0.x did. Arrays of arrays may still mix element types.
+	HomogeneousArrays bool
+
+	// DuplicateTables accepts a table declared by more than one [table]
+	// header; the keys of all of them are merged.
+	DuplicateTables bool
+}
+
+// A Version is a version of the TOML specification.
+type Version int
+
+const (
+	TOML10 Version = iota // TOML 1.0.0
+	TOML11                // TOML 1.1.0
+)
+
+func (v Version) String() string {
+	return fmt.Sprintf("1.%d", int(v))
+}
+
+// Legacy is the dialect accepted by earlier versions of this package.
+var Legacy = Dialect{
+	ColonSeparator:    true,
+	DashSeparators:    true,
+	HomogeneousArrays: true,
+	DuplicateTables:   true,
+}
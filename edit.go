@@ -0,0 +1,355 @@
+package toml
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Document editing.
+//
+// Edits are applied to the original text of the tree, touching only the
+// bytes of the affected entries, and the tree is re-parsed afterwards so
+// that its nodes always describe the current text.
+//
+// A path through an array of tables refers to its last element, the one a
+// table header would extend: with [[servers]] twice, servers.host is the
+// host of the second server. Get, Set, Delete, InsertTable and Keys all
+// follow this rule.
+
+// Get returns the value node stored at the dotted path, or the
+// *EntryGroupNode of the table at path. It returns nil if path is not
+// defined.
+func (t *Tree) Get(path string) Node {
+	keys, err := splitPath(path)
+	if err != nil {
+		return nil
+	}
+	if e := t.lookup(keys); e != nil {
+		return e.Value
+	}
+	if groups := t.groups(keys); len(groups) > 0 {
+		return groups[0]
+	}
+	return nil
+}
+
+// Set stores value at the dotted path. An existing value is replaced in
+// place, keeping any comment that follows it; a time.Time replacing a
+// local date, time or datetime is written in the same form. A new key is
+// added after the last entry of its table, and a missing table is created
+// first.
+func (t *Tree) Set(path string, value interface{}) error {
+	keys, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+	text, err := valueText(reflect.ValueOf(value))
+	if err != nil {
+		return err
+	}
+	if e := t.lookup(keys); e != nil {
+		// A time replacing a local datetime stays local.
+		if old, ok := e.Value.(*DatetimeNode); ok {
+			if tm, ok := value.(time.Time); ok {
+				text = datetimeText(tm, old.Kind)
+			}
+		}
+		return t.edit(e.Value.Position(), e.End, text)
+	}
+	if len(t.groups(keys)) > 0 {
+		return fmt.Errorf("toml: %q is a table", path)
+	}
+
+	table, key := keys[:len(keys)-1], keys[len(keys)-1]
+	if len(table) > 0 && len(t.groups(table)) == 0 {
+		if err := t.InsertTable(keysText(table)); err != nil {
+			return err
+		}
+	}
+	pos := t.entryInsertPos(table)
+	return t.insertLine(pos, fmt.Sprintf("%s = %s", keyText(key), text))
+}
+
+// Delete removes the key or table at the dotted path together with the
+// comment lines directly above it. Deleting a table also deletes its
+// sub-tables. A key of an inline table is removed with the comma
+// separating it from its neighbour.
+func (t *Tree) Delete(path string) error {
+	keys, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+	if e, table := t.find(keys); table != nil {
+		return t.deleteInline(e, table)
+	} else if e != nil {
+		start := t.leadingStart(t.lineStart(e.Pos))
+		return t.edit(start, t.lineEnd(e.End), "")
+	}
+	if len(t.groups(keys)) == 0 {
+		return fmt.Errorf("toml: %q is not defined", path)
+	}
+	// The last tables go first, so that the document stays valid while
+	// the tables of an element of an array of tables are deleted.
+	from := t.element(keys)
+	for {
+		i := -1
+		for j := len(t.Root.Nodes) - 1; j >= from && i < 0; j-- {
+			if g, ok := t.Root.Nodes[j].(*EntryGroupNode); ok && hasPrefix(g.KeyGroup.StringKeys(), keys) {
+				i = j
+			}
+		}
+		if i < 0 {
+			return nil
+		}
+		g := t.Root.Nodes[i]
+		start := t.leadingStart(t.lineStart(g.Position()))
+		if err := t.edit(start, t.groupEnd(i), ""); err != nil {
+			return err
+		}
+	}
+}
+
+// deleteInline removes entry e of the inline table, up to the next entry
+// or, for the last one, from the end of the previous entry.
+func (t *Tree) deleteInline(e *EntryNode, table *InlineTableNode) error {
+	nodes := table.Entries.Nodes
+	for i, n := range nodes {
+		switch {
+		case n != e:
+		case i+1 < len(nodes):
+			return t.edit(e.Pos, nodes[i+1].Position(), "")
+		case i > 0:
+			return t.edit(nodes[i-1].(*EntryNode).End, e.End, "")
+		default:
+			return t.edit(e.Pos, e.End, "")
+		}
+	}
+	return nil
+}
+
+// InsertTable adds an empty table at the dotted path. The new table is
+// placed after the last table sharing the longest common parent, or at the
+// end of the document. It does nothing if the table already exists.
+func (t *Tree) InsertTable(path string) error {
+	keys, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+	if len(t.groups(keys)) > 0 {
+		return nil
+	}
+	if t.lookup(keys) != nil {
+		return fmt.Errorf("toml: %q is not a table", path)
+	}
+
+	pos := Pos(len(t.text))
+	for n := len(keys) - 1; n > 0; n-- {
+		last := -1
+		for i, node := range t.Root.Nodes {
+			if g, ok := node.(*EntryGroupNode); ok && hasPrefix(g.KeyGroup.StringKeys(), keys[:n]) {
+				last = i
+			}
+		}
+		if last >= 0 {
+			pos = t.groupEnd(last)
+			break
+		}
+	}
+	return t.insertLine(pos, fmt.Sprintf("[%s]", keysText(keys)))
+}
+
+// WriteTo writes the current text of the document to w.
+func (t *Tree) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, t.text)
+	return int64(n), err
+}
+
+// keysText returns keys as dotted keys, quoting those that are not bare.
+func keysText(keys []string) string {
+	texts := make([]string, len(keys))
+	for i, key := range keys {
+		texts[i] = keyText(key)
+	}
+	return strings.Join(texts, ".")
+}
+
+func hasPrefix(keys, prefix []string) bool {
+	if len(keys) < len(prefix) {
+		return false
+	}
+	for i, k := range prefix {
+		if keys[i] != k {
+			return false
+		}
+	}
+	return true
+}
+
+func equalKeys(a, b []string) bool {
+	return len(a) == len(b) && hasPrefix(a, b)
+}
+
+// groups returns the entry groups declaring the table keys.
+func (t *Tree) groups(keys []string) []*EntryGroupNode {
+	groups := []*EntryGroupNode{}
+	for _, node := range t.Root.Nodes[t.element(keys):] {
+		if g, ok := node.(*EntryGroupNode); ok && equalKeys(g.KeyGroup.StringKeys(), keys) {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// element returns the index in Root of the header of the last element of
+// the innermost array of tables on the path keys, or 0 if there is none.
+// The tables at keys are declared at or after it.
+func (t *Tree) element(keys []string) int {
+	from := 0
+	for i, node := range t.Root.Nodes {
+		if g, ok := node.(*EntryGroupNode); ok && g.KeyGroup.Array && hasPrefix(keys, g.KeyGroup.StringKeys()) {
+			from = i
+		}
+	}
+	return from
+}
+
+// groupIndex returns the index in Root of the first entry group matching f, or -1.
+func (t *Tree) groupIndex(f func(*EntryGroupNode) bool) int {
+	for i, node := range t.Root.Nodes {
+		if g, ok := node.(*EntryGroupNode); ok && f(g) {
+			return i
+		}
+	}
+	return -1
+}
+
+// entries returns the entries of the table keys, the root table if keys is empty.
+func (t *Tree) entries(keys []string) []*EntryNode {
+	entries := []*EntryNode{}
+	if len(keys) == 0 {
+		for _, node := range t.Root.Nodes {
+			if e, ok := node.(*EntryNode); ok {
+				entries = append(entries, e)
+			}
+		}
+		return entries
+	}
+	for _, g := range t.groups(keys) {
+		for _, node := range g.Entries.Nodes {
+			entries = append(entries, node.(*EntryNode))
+		}
+	}
+	return entries
+}
+
+// lookup returns the entry at keys, or nil. The entry may be nested in
+// inline tables.
+func (t *Tree) lookup(keys []string) *EntryNode {
+	e, _ := t.find(keys)
+	return e
+}
+
+// find returns the entry at keys and the inline table holding it, nil
+// for an entry of a table declared by a header, or nil, nil.
+func (t *Tree) find(keys []string) (*EntryNode, *InlineTableNode) {
+	for n := len(keys) - 1; n >= 0; n-- {
+		if e, table := findEntry(t.entries(keys[:n]), keys[n:], nil); e != nil {
+			return e, table
+		}
+	}
+	return nil, nil
+}
+
+// findEntry returns the entry at keys among entries, the entries of the
+// inline table parent if it is not nil, descending into inline tables.
+// It also returns the inline table holding the entry.
+func findEntry(entries []*EntryNode, keys []string, parent *InlineTableNode) (*EntryNode, *InlineTableNode) {
+	for _, e := range entries {
+		if e.Key.Key != keys[0] {
+			continue
+		}
+		if len(keys) == 1 {
+			return e, parent
+		}
+		if table, ok := e.Value.(*InlineTableNode); ok {
+			inner := []*EntryNode{}
+			for _, n := range table.Entries.Nodes {
+				inner = append(inner, n.(*EntryNode))
+			}
+			return findEntry(inner, keys[1:], table)
+		}
+		return nil, nil
+	}
+	return nil, nil
+}
+
+// entryInsertPos returns where a new entry of the table keys goes: after
+// its last entry, after its header, or before the first table for the root.
+func (t *Tree) entryInsertPos(keys []string) Pos {
+	if entries := t.entries(keys); len(entries) > 0 {
+		return t.lineEnd(entries[len(entries)-1].End)
+	}
+	if len(keys) == 0 {
+		if i := t.groupIndex(func(*EntryGroupNode) bool { return true }); i >= 0 {
+			return t.leadingStart(t.lineStart(t.Root.Nodes[i].Position()))
+		}
+		return Pos(len(t.text))
+	}
+	groups := t.groups(keys)
+	g := groups[len(groups)-1]
+	return t.lineEnd(g.Position() + Pos(len(g.KeyGroup.Text)))
+}
+
+// groupEnd returns the end of the entry group at index i of Root, which is
+// where the next group and the comments above it start.
+func (t *Tree) groupEnd(i int) Pos {
+	for _, node := range t.Root.Nodes[i+1:] {
+		if g, ok := node.(*EntryGroupNode); ok {
+			return t.leadingStart(t.lineStart(g.Position()))
+		}
+	}
+	return Pos(len(t.text))
+}
+
+// lineStart returns the position of the first byte of the line containing pos.
+func (t *Tree) lineStart(pos Pos) Pos {
+	return Pos(strings.LastIndex(t.text[:pos], "\n") + 1)
+}
+
+// lineEnd returns the position just past the newline ending the line containing pos.
+func (t *Tree) lineEnd(pos Pos) Pos {
+	i := strings.Index(t.text[pos:], "\n")
+	if i < 0 {
+		return Pos(len(t.text))
+	}
+	return pos + Pos(i+1)
+}
+
+// leadingStart extends the line starting at pos upwards over the comment
+// lines directly above it.
+func (t *Tree) leadingStart(pos Pos) Pos {
+	for pos > 0 {
+		prev := t.lineStart(pos - 1)
+		if !strings.HasPrefix(strings.TrimLeft(t.text[prev:pos], " \t"), string(commentStart)) {
+			break
+		}
+		pos = prev
+	}
+	return pos
+}
+
+// insertLine inserts line at pos, which must be the start of a line or the end of the text.
+func (t *Tree) insertLine(pos Pos, line string) error {
+	if pos > 0 && t.text[pos-1] != '\n' {
+		line = "\n" + line
+	}
+	return t.edit(pos, pos, line+"\n")
+}
+
+// edit replaces the text between start and end with s and re-parses the tree.
+func (t *Tree) edit(start, end Pos, s string) error {
+	return t.reparse(t.text[:start] + s + t.text[end:])
+}
@@ -0,0 +1,258 @@
+package toml
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var editDoc = `title = "app" # the name
+[server]
+# where to listen
+host = "localhost"
+port = 8080 # default port
+[server.tls]
+enabled = false
+[client]
+retries = 3
+`
+
+func editTree(t *testing.T) *Tree {
+	tree, err := Parse(editDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func treeText(t *testing.T, tree *Tree) string {
+	b := new(bytes.Buffer)
+	if _, err := tree.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestTreeGet(t *testing.T) {
+	tree := editTree(t)
+	if n, ok := tree.Get("server.port").(*NumberNode); !ok || n.Int != 8080 {
+		t.Errorf("server.port = %v", tree.Get("server.port"))
+	}
+	if _, ok := tree.Get("server.tls").(*EntryGroupNode); !ok {
+		t.Errorf("server.tls = %v, want table", tree.Get("server.tls"))
+	}
+	if n := tree.Get("server.missing"); n != nil {
+		t.Errorf("server.missing = %v, want nil", n)
+	}
+}
+
+func TestTreeEdit(t *testing.T) {
+	tests := []struct {
+		edit func(*Tree) error
+		want string
+	}{
+		{
+			func(tree *Tree) error { return tree.Set("server.port", 9090) },
+			`title = "app" # the name
+[server]
+# where to listen
+host = "localhost"
+port = 9090 # default port
+[server.tls]
+enabled = false
+[client]
+retries = 3
+`,
+		},
+		{
+			func(tree *Tree) error { return tree.Set("server.tls.cert", "a.pem") },
+			`title = "app" # the name
+[server]
+# where to listen
+host = "localhost"
+port = 8080 # default port
+[server.tls]
+enabled = false
+cert = "a.pem"
+[client]
+retries = 3
+`,
+		},
+		{
+			func(tree *Tree) error { return tree.Set("server.log.levels", []string{"info"}) },
+			`title = "app" # the name
+[server]
+# where to listen
+host = "localhost"
+port = 8080 # default port
+[server.tls]
+enabled = false
+[server.log]
+levels = ["info"]
+[client]
+retries = 3
+`,
+		},
+		{
+			func(tree *Tree) error { return tree.Set("version", 2) },
+			`title = "app" # the name
+version = 2
+[server]
+# where to listen
+host = "localhost"
+port = 8080 # default port
+[server.tls]
+enabled = false
+[client]
+retries = 3
+`,
+		},
+		{
+			func(tree *Tree) error { return tree.Delete("server.host") },
+			`title = "app" # the name
+[server]
+port = 8080 # default port
+[server.tls]
+enabled = false
+[client]
+retries = 3
+`,
+		},
+		{
+			func(tree *Tree) error { return tree.Delete("server") },
+			`title = "app" # the name
+[client]
+retries = 3
+`,
+		},
+		{
+			func(tree *Tree) error { return tree.InsertTable("db") },
+			editDoc + "[db]\n",
+		},
+	}
+
+	for i, tt := range tests {
+		tree := editTree(t)
+		if err := tt.edit(tree); err != nil {
+			t.Errorf("%d: %s", i, err)
+			continue
+		}
+		if got := treeText(t, tree); got != tt.want {
+			t.Errorf("%d: got\n%s\nwant\n%s", i, got, tt.want)
+		}
+	}
+}
+
+func TestTreeDeleteInline(t *testing.T) {
+	tests := []struct {
+		doc, path, want string
+	}{
+		{"a = {x = 1, y = 2, z = 3}\nb = 1\n", "a.x", "a = {y = 2, z = 3}\nb = 1\n"},
+		{"a = {x = 1, y = 2, z = 3}\nb = 1\n", "a.y", "a = {x = 1, z = 3}\nb = 1\n"},
+		{"a = {x = 1, y = 2, z = 3}\nb = 1\n", "a.z", "a = {x = 1, y = 2}\nb = 1\n"},
+		{"a = {x = 1}\n", "a.x", "a = {}\n"},
+		{"[t]\na = {x = {p = 1, q = 2}} # c\n", "t.a.x.q", "[t]\na = {x = {p = 1}} # c\n"},
+	}
+	for _, tt := range tests {
+		tree, err := Parse(tt.doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tree.Delete(tt.path); err != nil {
+			t.Errorf("%s: %s", tt.path, err)
+			continue
+		}
+		if got := treeText(t, tree); got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestTreeSetTime(t *testing.T) {
+	tree, err := Parse("d = 1979-05-27\nt = 07:32:00\nl = 1979-05-27T07:32:00\no = 1979-05-27T07:32:00Z\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := time.Date(2000, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	for _, key := range []string{"d", "t", "l", "o"} {
+		if err := tree.Set(key, tm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "d = 2000-01-02\nt = 03:04:05.6\nl = 2000-01-02T03:04:05.6\no = 2000-01-02T03:04:05.6Z\n"
+	if got := treeText(t, tree); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTreeQuotedKeys(t *testing.T) {
+	tree, err := Parse("[a]\nx = 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Set(`a."b.c"`, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Set(`a."d e".f`, 3); err != nil {
+		t.Fatal(err)
+	}
+	want := "[a]\nx = 1\n\"b.c\" = 2\n[a.\"d e\"]\nf = 3\n"
+	if got := treeText(t, tree); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if v, _, err := tree.GetInt(`a."d e".f`); err != nil || v != 3 {
+		t.Errorf("GetInt = %v, %v", v, err)
+	}
+}
+
+func TestTreeArrayOfTables(t *testing.T) {
+	tree, err := Parse(`[[servers]]
+host = "a"
+[servers.tls]
+on = true
+
+[[servers]]
+host = "b"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host, _, err := tree.GetString("servers.host"); err != nil || host != "b" {
+		t.Errorf("GetString = %q, %v", host, err)
+	}
+	if keys, err := tree.Keys("servers"); err != nil || !reflect.DeepEqual(keys, []string{"host"}) {
+		t.Errorf("Keys = %q, %v", keys, err)
+	}
+	if tree.Has("servers.tls") {
+		t.Errorf("servers.tls is defined in the last server")
+	}
+	if err := tree.Set("servers.port", 80); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Set("servers.tls.on", false); err != nil {
+		t.Fatal(err)
+	}
+	want := "[[servers]]\nhost = \"a\"\n[servers.tls]\non = true\n\n[[servers]]\nhost = \"b\"\nport = 80\n[servers.tls]\non = false\n"
+	if got := treeText(t, tree); got != want {
+		t.Errorf("Set: got\n%s\nwant\n%s", got, want)
+	}
+	if err := tree.Delete("servers"); err != nil {
+		t.Fatal(err)
+	}
+	want = "[[servers]]\nhost = \"a\"\n[servers.tls]\non = true\n\n"
+	if got := treeText(t, tree); got != want {
+		t.Errorf("Delete: got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTreeSetNaN(t *testing.T) {
+	tree, err := Parse("x = 1.5\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Set("x", math.NaN()); err == nil || err.Error() != "toml: cannot encode float NaN" {
+		t.Errorf("Set NaN: %v", err)
+	}
+}
@@ -0,0 +1,442 @@
+package toml
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Marshal returns the TOML encoding of v, which must be a struct, a map
+// or an OrderedMap, or a pointer to one. Map keys must be strings,
+// integers or implement encoding.TextMarshaler.
+func Marshal(v interface{}) ([]byte, error) {
+	b := new(bytes.Buffer)
+	if err := NewEncoder(b).Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// An Encoder writes TOML documents to an output stream.
+type Encoder struct {
+	w       io.Writer
+	dialect Dialect
+	naming  NameFunc
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Dialect sets the dialect the output must conform to. Encode fails for
+// values the dialect cannot represent.
+func (enc *Encoder) Dialect(d Dialect) {
+	enc.dialect = d
+}
+
+// Naming sets how the keys of struct fields without a tag name are
+// formed, SnakeCase for example. By default the key is the field name.
+func (enc *Encoder) Naming(f NameFunc) {
+	enc.naming = f
+}
+
+// Encode writes the TOML encoding of v to the stream.
+//
+// Keys and values of a table are written before its sub-tables, which
+// follow as [table] sections. Struct fields are written in declaration
+// order, map keys sorted by their text and OrderedMap keys in their order.
+// Nil pointers and interfaces are left out.
+func (enc *Encoder) Encode(v interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(runtime.Error); ok {
+				panic(r)
+			}
+			err = r.(error)
+		}
+	}()
+
+	e := &encode{dialect: enc.dialect, naming: enc.naming}
+	rv := indirectValue(reflect.ValueOf(v))
+	if !isTable(rv) {
+		return fmt.Errorf("toml: cannot encode %s as a document", reflect.TypeOf(v))
+	}
+	e.table(nil, rv)
+	_, err = enc.w.Write(e.Bytes())
+	return err
+}
+
+type encode struct {
+	bytes.Buffer
+	dialect Dialect
+	naming  NameFunc
+}
+
+// error aborts the encoding by panicking with err.
+func (e *encode) errorf(format string, args ...interface{}) {
+	panic(fmt.Errorf(format, args...))
+}
+
+// A keyValue is a key of a table with its value.
+type keyValue struct {
+	key   string
+	value reflect.Value
+}
+
+// table writes the keys and values of table v, followed by its sub-tables.
+func (e *encode) table(path []string, v reflect.Value) {
+	entries := e.tableEntries(v)
+	for _, kv := range entries {
+		if !isTable(kv.value) {
+			fmt.Fprintf(e, "%s = %s\n", e.key(kv.key), e.value(kv.value))
+		}
+	}
+	for _, kv := range entries {
+		if isTable(kv.value) {
+			sub := append(path[:len(path):len(path)], kv.key)
+			if e.Len() > 0 {
+				e.WriteByte('\n')
+			}
+			keys := []string{}
+			for _, k := range sub {
+				keys = append(keys, e.key(k))
+			}
+			fmt.Fprintf(e, "[%s]\n", strings.Join(keys, "."))
+			e.table(sub, kv.value)
+		}
+	}
+}
+
+// tableEntries returns the keys and values of table v in encoding order,
+// leaving out nil values.
+func (e *encode) tableEntries(v reflect.Value) []keyValue {
+	entries := []keyValue{}
+	add := func(key string, value reflect.Value) {
+		if value = indirectValue(e.unionValue(value)); value.IsValid() {
+			entries = append(entries, keyValue{key, value})
+		}
+	}
+	switch {
+	case v.Type() == unionTableType:
+		u := v.Interface().(unionTable)
+		value := indirectValue(u.value)
+		if !isTable(value) {
+			e.errorf("toml: cannot encode %s as a table", value.Type())
+		}
+		add(u.key, reflect.ValueOf(u.name))
+		for _, kv := range e.tableEntries(value) {
+			if !strings.EqualFold(kv.key, u.key) {
+				entries = append(entries, kv)
+			}
+		}
+	case v.Type() == orderedMapType:
+		m := v.Addr().Interface().(*OrderedMap)
+		m.Range(func(key string, value interface{}) bool {
+			add(key, reflect.ValueOf(value))
+			return true
+		})
+	case v.Kind() == reflect.Map:
+		if !isMapKey(v.Type().Key()) {
+			e.errorf("toml: cannot encode map with %s keys", v.Type().Key())
+		}
+		keys := []keyValue{}
+		for _, k := range v.MapKeys() {
+			keys = append(keys, keyValue{e.mapKey(k), k})
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i].key < keys[j].key })
+		for _, k := range keys {
+			add(k.key, v.MapIndex(k.value))
+		}
+	default:
+		for _, f := range typeFields(v.Type(), e.naming) {
+			add(f.name, v.Field(f.index))
+		}
+	}
+	return entries
+}
+
+// mapKey returns the text of map key k.
+func (e *encode) mapKey(k reflect.Value) string {
+	if m, ok := k.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			e.errorf("toml: cannot encode key %v: %v", k, err)
+		}
+		return string(text)
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(k.Uint(), 10)
+	case reflect.String:
+		return k.String()
+	}
+	e.errorf("toml: cannot encode map with %s keys", k.Type())
+	return ""
+}
+
+// key returns key as written in the document, quoted unless it is bare.
+func (e *encode) key(key string) string {
+	return keyText(key)
+}
+
+// value returns the TOML representation of a Go value. Tables are
+// written as inline tables.
+func (e *encode) value(v reflect.Value) string {
+	v = indirectValue(v)
+	if !v.IsValid() {
+		e.errorf("toml: cannot encode nil value")
+	}
+	switch v.Type() {
+	case timeType:
+		return datetimeText(v.Interface().(time.Time), OffsetDatetime)
+	case numberType:
+		return v.String()
+	case bigIntType:
+		i := v.Interface().(big.Int)
+		return i.String()
+	case bigFloatType:
+		f := v.Interface().(big.Float)
+		return decimalText(f.Text('f', -1))
+	case bigRatType:
+		r := v.Interface().(big.Rat)
+		text, ok := ratText(&r)
+		if !ok {
+			e.errorf("toml: cannot encode %s: not a finite decimal", r.String())
+		}
+		return text
+	case localDateType, localTimeType, localDateTimeType:
+		return v.Interface().(fmt.Stringer).String()
+	case jsonNumberType:
+		return v.String()
+	case primitiveType:
+		p := v.Interface().(Primitive)
+		if p.Node == nil {
+			e.errorf("toml: cannot encode empty Primitive")
+		}
+		return p.Node.String()
+	case rawMessageType:
+		var x interface{}
+		dec := json.NewDecoder(bytes.NewReader(v.Bytes()))
+		dec.UseNumber()
+		if err := dec.Decode(&x); err != nil {
+			e.errorf("toml: cannot encode json.RawMessage: %v", err)
+		}
+		return e.value(reflect.ValueOf(x))
+	}
+	if isTable(v) {
+		values := []string{}
+		for _, kv := range e.tableEntries(v) {
+			values = append(values, fmt.Sprintf("%s = %s", e.key(kv.key), e.value(kv.value)))
+		}
+		return fmt.Sprintf("{%s}", strings.Join(values, ", "))
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			e.errorf("toml: cannot encode float %v", f)
+		}
+		return floatText(v.Float(), v.Type().Bits())
+	case reflect.String:
+		return quoteString(v.String())
+	case reflect.Array, reflect.Slice:
+		values := []string{}
+		first := ""
+		for i := 0; i < v.Len(); i++ {
+			elem := indirectValue(e.unionValue(v.Index(i)))
+			if kind := valueKind(elem); i == 0 {
+				first = kind
+			} else if e.dialect.HomogeneousArrays && kind != first {
+				e.errorf("toml: mixed types %s and %s in array", first, kind)
+			}
+			values = append(values, e.value(elem))
+		}
+		return fmt.Sprintf("[%s]", strings.Join(values, ", "))
+	}
+	e.errorf("toml: cannot encode value of type %s", v.Type())
+	return ""
+}
+
+// valueText returns the TOML representation of a Go value.
+func valueText(v reflect.Value) (text string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(runtime.Error); ok {
+				panic(r)
+			}
+			err = r.(error)
+		}
+	}()
+	return new(encode).value(v), nil
+}
+
+// datetimeText formats t as a datetime of the given kind, keeping the
+// fraction of its seconds.
+func datetimeText(t time.Time, kind DatetimeKind) string {
+	switch kind {
+	case LocalDatetime:
+		return dateTimeOf(t).String()
+	case LocalDate:
+		return dateOf(t).String()
+	case LocalTime:
+		return timeOfDayOf(t).String()
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// indirectValue follows pointers and interfaces to the value they hold,
+// returning the zero Value for nil.
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isTable reports whether v is encoded as a table.
+func isTable(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Struct:
+		switch v.Type() {
+		case timeType, bigIntType, bigFloatType, bigRatType, localDateType, localTimeType, localDateTimeType, primitiveType:
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// valueKind returns the name of the TOML type v is encoded as.
+func valueKind(v reflect.Value) string {
+	switch {
+	case !v.IsValid():
+		return "nil"
+	case v.Type() == timeType, v.Type() == localDateType, v.Type() == localTimeType, v.Type() == localDateTimeType:
+		return "datetime"
+	case v.Type() == primitiveType && v.Interface().(Primitive).Node != nil:
+		return nodeKind(v.Interface().(Primitive).Node)
+	case (v.Type() == numberType || v.Type() == jsonNumberType) && strings.ContainsAny(v.String(), ".eE"):
+		return "float"
+	case v.Type() == numberType, v.Type() == jsonNumberType, v.Type() == bigIntType:
+		return "integer"
+	case v.Type() == bigFloatType, v.Type() == bigRatType:
+		return "float"
+	case isTable(v):
+		return "table"
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.String:
+		return "string"
+	case reflect.Array, reflect.Slice:
+		return "array"
+	}
+	return v.Type().String()
+}
+
+// floatText formats f so that it always reads back as a float. The lexer
+// reads no exponents, so large and tiny magnitudes are written out in
+// full: 1e300 takes 301 digits.
+func floatText(f float64, bits int) string {
+	return decimalText(strconv.FormatFloat(f, 'f', -1, bits))
+}
+
+// ratText formats r as a decimal float, which it reports it cannot do if
+// the denominator of r has prime factors other than 2 and 5.
+func ratText(r *big.Rat) (string, bool) {
+	d := new(big.Int).Set(r.Denom())
+	digits := 0
+	for _, p := range []int64{2, 5} {
+		n, m, q := 0, new(big.Int), big.NewInt(p)
+		for {
+			quo, _ := new(big.Int).QuoRem(d, q, m)
+			if m.Sign() != 0 {
+				break
+			}
+			d, n = quo, n+1
+		}
+		digits = max(digits, n)
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return "", false
+	}
+	return decimalText(r.FloatString(digits)), true
+}
+
+// decimalText adds a fractional part to a decimal number without one.
+func decimalText(s string) string {
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+// keyText returns key as a bare key if possible, quoted otherwise.
+func keyText(key string) string {
+	if key == "" || strings.IndexFunc(key, func(r rune) bool { return !isBareKey(r) }) >= 0 {
+		return quoteString(key)
+	}
+	return key
+}
+
+// quoteString returns s as a basic TOML string.
+func quoteString(s string) string {
+	b := make([]byte, 0, len(s)+2)
+	b = append(b, '"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b = append(b, `\"`...)
+		case '\\':
+			b = append(b, `\\`...)
+		case '\b':
+			b = append(b, `\b`...)
+		case '\t':
+			b = append(b, `\t`...)
+		case '\n':
+			b = append(b, `\n`...)
+		case '\f':
+			b = append(b, `\f`...)
+		case '\r':
+			b = append(b, `\r`...)
+		default:
+			if unicode.IsControl(r) {
+				b = append(b, fmt.Sprintf(`\u%04X`, r)...)
+			} else {
+				b = append(b, string(r)...)
+			}
+		}
+	}
+	b = append(b, '"')
+	return string(b)
+}
@@ -0,0 +1,339 @@
+package toml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type encodeServer struct {
+	Host  string
+	Port  int
+	Tags  []string `toml:"labels"`
+	Debug *bool
+	skip  int
+}
+
+type encodeConfig struct {
+	Title   string
+	Started time.Time
+	Server  encodeServer
+	Limits  map[string]float64
+	Points  []map[string]int
+	Ignored int `toml:"-"`
+}
+
+func TestMarshal(t *testing.T) {
+	c := encodeConfig{
+		Title:   "app",
+		Started: time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+		Server:  encodeServer{Host: "localhost", Port: 8080, Tags: []string{"a", "b\"c"}},
+		Limits:  map[string]float64{"cpu": 1.5, "mem": 2},
+		Points:  []map[string]int{{"x": 1}, {"x": 2}},
+	}
+	b, err := Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Title = "app"
+Started = 1979-05-27T07:32:00Z
+Points = [{x = 1}, {x = 2}]
+
+[Server]
+Host = "localhost"
+Port = 8080
+labels = ["a", "b\"c"]
+
+[Limits]
+cpu = 1.5
+mem = 2.0
+`
+	if string(b) != want {
+		t.Errorf("got\n%s\nwant\n%s", b, want)
+	}
+
+	var back encodeConfig
+	if err := Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, c) {
+		t.Errorf("round trip: got %+v, want %+v", back, c)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := []struct {
+		v   interface{}
+		err string
+	}{
+		{42, "toml: cannot encode int as a document"},
+		{map[string]interface{}{"a": []interface{}{nil}}, "toml: cannot encode nil value"},
+		{map[string]interface{}{"a": math.NaN()}, "toml: cannot encode float NaN"},
+		{map[string]float32{"a": float32(math.Inf(-1))}, "toml: cannot encode float -Inf"},
+	}
+	for _, tt := range tests {
+		if _, err := Marshal(tt.v); err == nil || err.Error() != tt.err {
+			t.Errorf("%v: got error %v, want %q", tt.v, err, tt.err)
+		}
+	}
+
+	enc := NewEncoder(new(bytes.Buffer))
+	enc.Dialect(Dialect{HomogeneousArrays: true})
+	err := enc.Encode(map[string]interface{}{"a": []interface{}{1, "x"}})
+	if err == nil || err.Error() != "toml: mixed types integer and string in array" {
+		t.Errorf("homogeneous: %v", err)
+	}
+}
+
+func TestOrderedMapRoundTrip(t *testing.T) {
+	doc := `zeta = 1
+alpha = "x"
+mid = [1, 2]
+
+[server]
+port = 80
+host = "h"
+
+[server.tls]
+on = true
+
+[beta]
+b = 2
+a = 1
+`
+	var v interface{}
+	dec := NewDecoder(strings.NewReader(doc))
+	dec.UseOrderedMap()
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	m, ok := v.(*OrderedMap)
+	if !ok {
+		t.Fatalf("decoded %T, want *OrderedMap", v)
+	}
+	if keys := m.Keys(); !reflect.DeepEqual(keys, []string{"zeta", "alpha", "mid", "server", "beta"}) {
+		t.Errorf("Keys = %v", keys)
+	}
+	b, err := Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != doc {
+		t.Errorf("got\n%s\nwant\n%s", b, doc)
+	}
+
+	var om OrderedMap
+	if err := Unmarshal([]byte(doc), &om); err != nil || om.Len() != 5 {
+		t.Errorf("OrderedMap target: %d keys, %v", om.Len(), err)
+	}
+}
+
+func TestOrderedMapNested(t *testing.T) {
+	const doc = `z = 1
+
+[b]
+y = 2
+x = 3
+
+[b.d]
+w = 4
+v = [{u = 5, t = 6}]
+
+[a]
+
+[a.s]
+r = 7
+q = 8
+`
+	var om OrderedMap
+	if err := Unmarshal([]byte(doc), &om); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := om.Get("b")
+	if _, ok := b.(*OrderedMap); !ok {
+		t.Fatalf("b = %T, want *OrderedMap", b)
+	}
+	out, err := Marshal(&om)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != doc {
+		t.Errorf("got\n%s\nwant\n%s", out, doc)
+	}
+}
+
+type keyName string
+
+type keyLevel int
+
+var keyLevels = []string{"debug", "info", "warn"}
+
+func (l keyLevel) MarshalText() ([]byte, error) {
+	return []byte(keyLevels[l]), nil
+}
+
+func (l *keyLevel) UnmarshalText(text []byte) error {
+	for i, s := range keyLevels {
+		if s == string(text) {
+			*l = keyLevel(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown level %q", text)
+}
+
+func TestMapKeys(t *testing.T) {
+	type config struct {
+		Ports  map[uint16]string
+		Offset map[int8]int
+		Names  map[keyName]bool
+		Levels map[keyLevel]int
+		Hosts  map[netip.Addr]string
+	}
+	c := config{
+		Ports:  map[uint16]string{80: "http", 443: "https"},
+		Offset: map[int8]int{-1: 1},
+		Names:  map[keyName]bool{"a": true},
+		Levels: map[keyLevel]int{0: 10, 2: 30},
+		Hosts:  map[netip.Addr]string{netip.MustParseAddr("10.0.0.1"): "a", netip.MustParseAddr("::1"): "b"},
+	}
+	b, err := Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "[Ports]\n443 = \"https\"\n80 = \"http\"\n") || !strings.Contains(string(b), "[Levels]\ndebug = 10\nwarn = 30\n") ||
+		!strings.Contains(string(b), "[Hosts]\n\"10.0.0.1\" = \"a\"\n\"::1\" = \"b\"\n") {
+		t.Errorf("Marshal:\n%s", b)
+	}
+	var back config
+	if err := Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, c) {
+		t.Errorf("round trip: got %+v, want %+v", back, c)
+	}
+
+	errs := map[string]string{
+		"[Ports]\nx = \"\"\n":   `toml: Ports.x (line 2): cannot decode key "x" into uint16`,
+		"[Offset]\n300 = 1\n":   `toml: Offset.300 (line 2): cannot decode key "300" into int8`,
+		"[Levels]\nfatal = 1\n": `toml: Levels.fatal (line 2): cannot decode key "fatal" into toml.keyLevel: unknown level "fatal"`,
+	}
+	for doc, want := range errs {
+		if err := Unmarshal([]byte(doc), &back); err == nil || err.Error() != want {
+			t.Errorf("%q: got error %v, want %q", doc, err, want)
+		}
+	}
+	var bad map[float64]int
+	if err := Unmarshal([]byte("1 = 1\n"), &bad); err == nil {
+		t.Errorf("decoded into map[float64]int")
+	}
+}
+
+func TestMarshalBigRat(t *testing.T) {
+	type prices struct {
+		Price big.Rat
+		Share *big.Rat
+		Count big.Rat
+	}
+	v := prices{Share: big.NewRat(-1, 8)}
+	v.Price.SetString("19.99")
+	v.Count.SetInt64(3)
+	b, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "Price = 19.99\nShare = -0.125\nCount = 3.0\n" {
+		t.Errorf("got\n%s", b)
+	}
+	var back prices
+	if err := Unmarshal(b, &back); err != nil || back.Price.Cmp(&v.Price) != 0 || back.Share.Cmp(v.Share) != 0 || back.Count.Cmp(&v.Count) != 0 {
+		t.Errorf("round trip: %+v, %v", back, err)
+	}
+	v.Price.SetFrac64(1, 3)
+	if _, err := Marshal(v); err == nil || err.Error() != "toml: cannot encode 1/3: not a finite decimal" {
+		t.Errorf("1/3: %v", err)
+	}
+}
+
+func TestQuotedKeys(t *testing.T) {
+	m := map[string]interface{}{
+		"a.b": map[string]interface{}{
+			"c d": map[string]interface{}{"": 1, "é": "x"},
+		},
+		"q\"": []interface{}{map[string]interface{}{"k": true}},
+	}
+	b, err := Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `"q\"" = [{k = true}]
+
+["a.b"]
+
+["a.b"."c d"]
+"" = 1
+"é" = "x"
+`
+	if string(b) != want {
+		t.Errorf("got\n%s\nwant\n%s", b, want)
+	}
+	var back map[string]interface{}
+	if err := Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, map[string]interface{}{
+		"a.b": map[string]interface{}{
+			"c d": map[string]interface{}{"": int64(1), "é": "x"},
+		},
+		"q\"": []interface{}{map[string]interface{}{"k": true}},
+	}) {
+		t.Errorf("round trip: %v", back)
+	}
+}
+
+func TestMarshalDeferred(t *testing.T) {
+	var v struct {
+		P Primitive
+		R json.RawMessage
+	}
+	if err := Unmarshal([]byte("P = {a = [1, 2]}\nR = {b = 1.5, c = \"x\"}\n"), &v); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Marshal(v)
+	if want := "P = {a = [1, 2]}\nR = {b = 1.5, c = \"x\"}\n"; err != nil || string(b) != want {
+		t.Errorf("Marshal = %q, %v, want %q", b, err, want)
+	}
+}
+
+func TestMarshalTimes(t *testing.T) {
+	v := struct {
+		At    time.Time
+		Day   Date
+		Clock TimeOfDay
+		Local DateTime
+	}{
+		At:    time.Date(1979, 5, 27, 7, 32, 0, 999999000, time.FixedZone("", -7*3600)),
+		Day:   Date{1979, 5, 27},
+		Clock: TimeOfDay{7, 32, 0, 500000000},
+		Local: DateTime{Date{1979, 5, 27}, TimeOfDay{7, 32, 0, 0}},
+	}
+	b, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "At = 1979-05-27T07:32:00.999999-07:00\nDay = 1979-05-27\nClock = 07:32:00.5\nLocal = 1979-05-27T07:32:00\n"
+	if string(b) != want {
+		t.Errorf("got\n%s\nwant\n%s", b, want)
+	}
+	var back struct{ At time.Time }
+	if err := Unmarshal(b, &back); err != nil || !back.At.Equal(v.At) {
+		t.Errorf("round trip: %v, %v", back.At, err)
+	}
+}
@@ -0,0 +1,62 @@
+package toml
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The categories of parse and decode errors. Every error Parse and Decode
+// return because of the document wraps one of them, so errors.Is tells
+// which; errors about the Go types and tags decoded into, and errors
+// returned by hooks, do not.
+var (
+	ErrSyntax          = errors.New("toml: syntax error")
+	ErrDuplicateKey    = errors.New("toml: duplicate key")
+	ErrTableRedefined  = errors.New("toml: table redefined")
+	ErrInvalidDatetime = errors.New("toml: invalid datetime")
+	ErrIntegerOverflow = errors.New("toml: integer overflow")
+	ErrUnknownField    = errors.New("toml: unknown field")
+	ErrMissingField    = errors.New("toml: missing field")
+	ErrTypeMismatch    = errors.New("toml: type mismatch")
+	ErrInvalidValue    = errors.New("toml: invalid value")
+)
+
+// A ParseError describes a document that is not valid TOML.
+type ParseError struct {
+	Line    int
+	Message string
+	Err     error // the category: ErrSyntax, ErrDuplicateKey, ...
+}
+
+func (e *ParseError) Error() string {
+	if e.Err != ErrSyntax {
+		// The message names the problem.
+		return fmt.Sprintf("%d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("%d: syntax error: %s", e.Line, e.Message)
+}
+
+// Unwrap returns the category of the error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// A kindError is an error message of a category.
+type kindError struct {
+	msg  string
+	kind error
+}
+
+// newError returns a formatted error of category kind, or of no category
+// if kind is nil.
+func newError(kind error, format string, args ...interface{}) error {
+	return &kindError{fmt.Sprintf(format, args...), kind}
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
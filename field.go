@@ -0,0 +1,192 @@
+package toml
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// A NameFunc turns the name of a struct field without a tag name into its
+// key, for decoding and encoding.
+type NameFunc func(field string) string
+
+// ExactCase uses the field name as the key.
+func ExactCase(field string) string {
+	return field
+}
+
+// SnakeCase writes the words of the field name in lower case joined by
+// underscores: MaxConns becomes max_conns and HTTPPort http_port.
+func SnakeCase(field string) string {
+	return strings.ToLower(strings.Join(words(field), "_"))
+}
+
+// KebabCase writes the words of the field name in lower case joined by
+// dashes: MaxConns becomes max-conns.
+func KebabCase(field string) string {
+	return strings.ToLower(strings.Join(words(field), "-"))
+}
+
+// CamelCase writes the field name with its first word in lower case:
+// MaxConns becomes maxConns and HTTPPort httpPort.
+func CamelCase(field string) string {
+	w := words(field)
+	if len(w) > 0 {
+		w[0] = strings.ToLower(w[0])
+	}
+	return strings.Join(w, "")
+}
+
+// words splits a Go identifier into words at changes of case and at
+// underscores. A run of capitals is a word of its own, except for the
+// last one when a lower-case letter follows: HTTPServer2 is HTTP Server2.
+func words(name string) []string {
+	words := []string{}
+	r := []rune(name)
+	start := 0
+	for i := 1; i <= len(r); i++ {
+		switch {
+		case i == len(r):
+		case r[i] == '_':
+		case unicode.IsUpper(r[i]) && !unicode.IsUpper(r[i-1]) && r[i-1] != '_':
+		case unicode.IsUpper(r[i]) && i+1 < len(r) && unicode.IsLower(r[i+1]) && unicode.IsUpper(r[i-1]):
+		default:
+			continue
+		}
+		if w := strings.Trim(string(r[start:i]), "_"); w != "" {
+			words = append(words, w)
+		}
+		start = i
+	}
+	return words
+}
+
+// A field describes a struct field that holds a TOML key.
+type field struct {
+	name       string   // key name: the tag name, or the field name.
+	tagged     bool     // whether name comes from a struct tag.
+	aliases    []string // former key names, from alias= tag options.
+	deprecated bool     // whether the key itself is deprecated.
+	required   bool     // whether the document must set the key.
+	append     bool     // whether loader layers append to the array.
+	index      int
+	typ        reflect.Type
+}
+
+// match returns the name of f that key matches: its name or one of its
+// aliases.
+func (f *field) match(key string, caseSensitive bool) (string, bool) {
+	for i := -1; i < len(f.aliases); i++ {
+		name := f.name
+		if i >= 0 {
+			name = f.aliases[i]
+		}
+		if name == key || !caseSensitive && strings.EqualFold(name, key) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// typeFields returns the exported fields of struct type t that hold keys,
+// in declaration order. A field tagged `toml:"-"` holds none. The keys of
+// fields without a tag name are given by naming, if it is not nil.
+//
+// The tag options alias=name, which may be repeated, give former names
+// of the key, deprecated marks the key itself as deprecated and required
+// makes the key mandatory in the tables declared for the struct, and
+// append makes the arrays of later Loader layers add to the array instead
+// of replacing it: `toml:"listen_addr,alias=bind,deprecated"`.
+func typeFields(t reflect.Type, naming NameFunc) []field {
+	fields := []field{}
+	for i := 0; i < t.NumField(); i++ {
+		tf := t.Field(i)
+		if tf.PkgPath != "" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tf.Tag.Get("toml"), ",")
+		if name == "-" {
+			continue
+		}
+		f := field{name: name, tagged: name != "", index: i, typ: tf.Type}
+		for _, opt := range strings.Split(opts, ",") {
+			switch {
+			case strings.HasPrefix(opt, "alias="):
+				f.aliases = append(f.aliases, strings.TrimPrefix(opt, "alias="))
+			case opt == "deprecated":
+				f.deprecated = true
+			case opt == "required":
+				f.required = true
+			case opt == "append":
+				f.append = true
+			}
+		}
+		switch {
+		case name != "":
+		case naming != nil:
+			f.name = naming(tf.Name)
+		default:
+			f.name = tf.Name
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// suggest returns the keys of fields that key may be a misspelling of,
+// closest first: keys equal to it but for case, dashes and underscores,
+// then keys within an edit distance of a third of its length.
+func suggest(fields []field, key string) []string {
+	type match struct {
+		name string
+		dist int
+	}
+	k := normalize(key)
+	matches := []match{}
+	for _, f := range fields {
+		if dist := editDistance(k, normalize(f.name)); dist*3 <= len(k) {
+			matches = append(matches, match{f.name, dist})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].dist < matches[j].dist
+	})
+	var names []string
+	for i := 0; i < len(matches) && i < 3; i++ {
+		names = append(names, matches[i].name)
+	}
+	return names
+}
+
+// normalize returns key in lower case without dashes and underscores.
+func normalize(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+}
+
+// editDistance returns the number of runes to insert, delete, replace or
+// swap with their neighbour to turn a into b.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
@@ -1,16 +1,16 @@
 package toml
 
 import (
+	"fmt"
 	"strings"
 	"unicode/utf8"
-	"fmt"
 	//"unicode"
 )
 
 type tokenType int
 
 const (
-	tokenError  tokenType = iota
+	tokenError tokenType = iota
 	tokenEOF
 	tokenSpace
 	tokenKeyGroup
@@ -23,29 +23,27 @@ const (
 	tokenArrayStart
 	tokenArrayEnd
 	tokenArraySep
+	tokenInlineTableStart
+	tokenInlineTableEnd
+	tokenInlineTableSep
 )
 
 const (
-	eof = -1
+	eof           = -1
 	keyGroupStart = '['
 	keyGroupEnd   = ']'
 	keyGroupSep   = '.'
 	keySep        = '='
-	keySep2        = ':'
+	keySep2       = ':'
 	commentStart  = '#'
 )
 
-var	datetimeFormat = []rune{
-	'0','0','0','0', '-', '0','0', '-', '0','0',
-	'T',
-	'0', '0', ':', '0', '0', ':', '0', '0',
-	'Z',
-}
+const datetimeChars = "0123456789-:.TtZz+"
 
 type token struct {
-	typ tokenType  // type.
+	typ tokenType // type.
 	pos Pos
-	val string     // value.
+	val string // value.
 }
 
 func (t token) String() string {
@@ -64,24 +62,28 @@ func (t token) String() string {
 type stateFn func(*lexer) stateFn
 
 type lexer struct {
-	input      string
-	state      stateFn
-	pos        Pos
-	start      Pos
-	width      Pos
-	lastPos    Pos
-	tokens     chan token
-	arrayDepth int
-}
-
-// lex creates a new scanner for the input string.
-func lex(input string) *lexer {
-	l := &lexer{
-		input:      input,
-		tokens:      make(chan token),
+	input   string
+	state   stateFn
+	pos     Pos
+	start   Pos
+	width   Pos
+	lastPos Pos
+	tokens  []token   // emitted tokens not yet returned by nextToken.
+	emitted bool      // whether a token has been emitted yet.
+	prev    tokenType // type of the last token emitted.
+	nesting []rune    // open '[' and '{' of the current value.
+	dialect Dialect
+	version Version // lowest TOML version accepting the input so far.
+}
+
+// lex creates a new scanner for the input string. The scanner runs only
+// when the parser asks for the next token.
+func lex(input string, dialect Dialect) *lexer {
+	return &lexer{
+		input:   input,
+		state:   lexStart,
+		dialect: dialect,
 	}
-	go l.run()
-	return l
 }
 
 // next returns the next rune in the input.
@@ -108,10 +110,6 @@ func (l *lexer) backup() {
 	l.pos -= l.width
 }
 
-func (l *lexer) is(word string) bool {
-	return strings.HasPrefix(l.input[l.pos-l.width:], word)
-}
-
 // accept consumes the next rune if it's from the valid set.
 func (l *lexer) accept(valid string) bool {
 	if strings.IndexRune(valid, l.next()) >= 0 {
@@ -128,32 +126,63 @@ func (l *lexer) acceptRun(valid string) {
 	l.backup()
 }
 
-func (l *lexer) run() {
-	for l.state = lexStart; l.state != nil; {
-		l.state = l.state(l)
-	}
-}
-
-// emit passes an token back to the client.
+// emit queues a token for the client.
 func (l *lexer) emit(t tokenType) {
-	l.tokens <- token{t, l.start, l.input[l.start:l.pos]}
+	l.tokens = append(l.tokens, token{t, l.start, l.input[l.start:l.pos]})
 	l.start = l.pos
+	l.emitted = true
+	l.prev = t
 }
 
-// errorf returns an error token and terminates the scan by passing
-// back a nil pointer that will be the next state, terminating l.nextItem.
+// errorf queues an error token and terminates the scan by passing
+// back a nil pointer that will be the next state, terminating l.nextToken.
 func (l *lexer) errorf(format string, args ...interface{}) stateFn {
-	l.tokens <- token{tokenError, l.start, fmt.Sprintf(format, args...)}
+	l.tokens = append(l.tokens, token{tokenError, l.start, fmt.Sprintf(format, args...)})
 	return nil
 }
 
+// require records that the input needs TOML version v for feature, or
+// fails if the dialect accepts an earlier version only.
+func (l *lexer) require(v Version, feature string) bool {
+	if l.dialect.Version < v {
+		l.errorf("%s requires TOML %s", feature, v)
+		return false
+	}
+	if l.version < v {
+		l.version = v
+	}
+	return true
+}
+
+// inArray reports whether the innermost open value is an array.
+func (l *lexer) inArray() bool {
+	return len(l.nesting) > 0 && l.nesting[len(l.nesting)-1] == '['
+}
+
+// inInlineTable reports whether the innermost open value is an inline table.
+func (l *lexer) inInlineTable() bool {
+	return len(l.nesting) > 0 && l.nesting[len(l.nesting)-1] == '{'
+}
+
 // ignore skips over the pending input before this point.
 func (l *lexer) ignore() {
 	l.start = l.pos
 }
 
+// nextToken runs the state machine until a token is queued and returns
+// it. Once the scan has terminated it keeps returning the last token,
+// which is the EOF or error token that terminated it.
 func (l *lexer) nextToken() token {
-	token := <-l.tokens
+	for len(l.tokens) == 0 && l.state != nil {
+		l.state = l.state(l)
+	}
+	if len(l.tokens) == 0 {
+		return token{tokenEOF, l.pos, ""}
+	}
+	token := l.tokens[0]
+	if len(l.tokens) > 1 || l.state != nil {
+		l.tokens = l.tokens[1:]
+	}
 	l.lastPos = token.pos
 	return token
 }
@@ -168,14 +197,15 @@ func lexStart(l *lexer) stateFn {
 	case r == eof:
 		l.emit(tokenEOF)
 		return nil
-  case isDash(r):
-		l.ignore()
-		return lexStart
+	case isDash(r) && l.dialect.DashSeparators && isDashLine(l.input[l.start:]):
+		if l.emitted {
+			l.pos = Pos(len(l.input))
+			l.ignore()
+			l.emit(tokenEOF)
+			return nil
+		}
+		return lexComment(l, lexStart)
 	case isNewLine(r):
-    if isNewLine(l.peek()) {
-        l.emit(tokenEOF)
-        return nil
-      }
 		l.ignore()
 		return lexStart
 	case isSpace(r):
@@ -185,16 +215,16 @@ func lexStart(l *lexer) stateFn {
 		return lexComment(l, lexStart)
 	case r == keyGroupStart:
 		return lexKeyGroup
-	case isAlpha(r):
+	case isBareKey(r) || r == '"':
+		l.backup()
 		return lexKey
 	default:
 		return l.errorf("lexStart parse error %#U", r)
 	}
-	return nil
 }
 
 func lexComment(l *lexer, nextState stateFn) stateFn {
-	for { 
+	for {
 		if r := l.next(); r == '\n' || r == eof {
 			l.backup()
 			break
@@ -204,111 +234,253 @@ func lexComment(l *lexer, nextState stateFn) stateFn {
 	return nextState
 }
 
-func lexKeyGroup(l *lexer) stateFn { 
+func lexKeyGroup(l *lexer) stateFn {
+	array := l.accept("[")
 Loop:
 	for {
 		switch r := l.next(); {
 		case r == keyGroupEnd:
+			if array && l.next() != keyGroupEnd {
+				l.backup()
+				return l.errorf("expected ']]' after array of tables name")
+			}
 			break Loop
-		case isAlphaNumeric(r) || r == keyGroupSep:
+		case isBareKey(r) || r == keyGroupSep:
 			// absorb.
+		case r == '"':
+			if !lexQuotedKey(l) {
+				return nil
+			}
 		default:
 			l.backup()
 			return l.errorf("bad keygroup name %#U", r)
 		}
 	}
 	l.emit(tokenKeyGroup)
-	return lexStart
+	return lexLineEnd
 }
 
 func lexKey(l *lexer) stateFn {
-Loop:
-	for {
-		switch r := l.next(); {
-		case isAlphaNumeric(r):
-			// absorb.
-		case isSpace(r):
-			l.backup()
-			break Loop
-    default:
-			l.backup()
-			return l.errorf("bad keyname %#U", r)
+	if l.peek() == '"' {
+		l.next()
+		if !lexQuotedKey(l) {
+			return nil
+		}
+	} else {
+		for isBareKey(l.peek()) {
+			l.next()
 		}
 	}
+	if r := l.peek(); !isSpace(r) && r != keySep && r != keySep2 {
+		return l.errorf("bad keyname %#U", r)
+	}
 	l.emit(tokenKey)
 	return lexKeySep
 }
 
+// lexQuotedKey scans a quoted key after its opening quote. It reports
+// whether the key ends on its line.
+func lexQuotedKey(l *lexer) bool {
+	for {
+		switch l.next() {
+		case '\\':
+			if l.next() == eof {
+				l.errorf("unterminated quoted key")
+				return false
+			}
+		case eof, '\n':
+			l.errorf("unterminated quoted key")
+			return false
+		case '"':
+			return true
+		}
+	}
+}
+
 func lexKeySep(l *lexer) stateFn {
 	ignoreSpaces(l)
 
 	r := l.next()
-	if r == keySep || r == keySep2 {
+	if r == keySep || r == keySep2 && l.dialect.ColonSeparator {
 		l.emit(tokenKeySep)
 		return lexValue
 	}
 	return l.errorf("bad key seperator %#U, want %#U", r, keySep)
 }
 
+// lexValue scans a value after a key separator, or the elements of an
+// array. Outside arrays a value must follow on the same line.
 func lexValue(l *lexer) stateFn {
 	//pd("value %q", l.peek())
-	switch r := l.next(); { 
-	case r == eof:
-		l.emit(tokenEOF)
-		return nil
-	case r == '\r':
-		l.ignore()
-		return lexValue
-	case r == '\n':
-		l.ignore()
-		if l.arrayDepth == 0 {
-			return lexStart
-		} else {
-			return lexValue
-		}
+	switch r := l.next(); {
 	case isSpace(r):
 		ignoreSpaces(l)
 		return lexValue
-	case r == commentStart:
+	case l.inArray() && isNewLine(r):
+		l.ignore()
+		return lexValue
+	case l.inArray() && r == commentStart:
 		return lexComment(l, lexValue)
+	case r == eof || isNewLine(r) || r == commentStart:
+		l.backup()
+		return l.errorf("expected value, found %s", describe(r))
 	case r == '"':
 		return lexString
 	case r == '[':
-		l.arrayDepth ++
+		l.nesting = append(l.nesting, r)
 		l.emit(tokenArrayStart)
 		return lexValue
 	case r == ']':
-		l.arrayDepth --
-		if l.arrayDepth < 0 {
+		if !l.inArray() {
 			return l.errorf("unexpected array end %#U", r)
 		}
+		l.nesting = l.nesting[:len(l.nesting)-1]
 		l.emit(tokenArrayEnd)
-		return lexValue
+		return lexValueEnd
 	case r == ',':
-		if l.arrayDepth > 0 {
+		if l.inArray() {
 			l.emit(tokenArraySep)
 			return lexValue
 		} else {
 			return l.errorf("unexpected comma outside array")
 		}
+	case r == '{':
+		l.nesting = append(l.nesting, r)
+		l.emit(tokenInlineTableStart)
+		return lexInlineTableKey
 	case r == '+' || r == '-':
 		l.backup()
 		return lexNumber
 	case '0' <= r && r <= '9':
 		l.backup()
 		return lexNumberOrDatetime
-	case l.is("true"):
-		l.pos += Pos(3)
-		l.emit(tokenBool)
-		return lexStart
-	case l.is("false"):
-		l.pos += Pos(4)
+	case isAlpha(r):
+		l.backup()
+		return lexKeyword
+	default:
+		return l.errorf("bad value %#U", r)
+	}
+}
+
+// lexKeyword scans a bare word value, which must be true or false.
+func lexKeyword(l *lexer) stateFn {
+	for isAlphaNumeric(l.next()) {
+		// absorb.
+	}
+	l.backup()
+	switch word := l.input[l.start:l.pos]; word {
+	case "true", "false":
 		l.emit(tokenBool)
+		return lexValueEnd
+	default:
+		return l.errorf("bad value %q", word)
+	}
+}
+
+// lexValueEnd checks what follows a complete value: a separator inside an
+// array or inline table, or the end of the line outside.
+func lexValueEnd(l *lexer) stateFn {
+	r := l.peek()
+	switch {
+	case l.inArray():
+		if isSpace(r) || isNewLine(r) || r == commentStart || r == ',' || r == ']' {
+			return lexValue
+		}
+		return l.errorf("expected ',' or ']' after array element, found %s", describe(r))
+	case l.inInlineTable():
+		return lexInlineTableNext
+	}
+	if isSpace(r) || isNewLine(r) || r == commentStart || r == eof {
+		return lexLineEnd
+	}
+	return l.errorf("expected newline after value, found %s", describe(r))
+}
+
+// lexInlineSpace skips spaces in an inline table, and newlines and
+// comments where TOML 1.1 allows them. It reports false on error.
+func lexInlineSpace(l *lexer) bool {
+	for {
+		switch r := l.next(); {
+		case isSpace(r):
+			// absorb.
+		case isNewLine(r):
+			if !l.require(TOML11, "newline in inline table") {
+				return false
+			}
+		case r == commentStart:
+			if !l.require(TOML11, "comment in inline table") {
+				return false
+			}
+			lexComment(l, nil)
+		default:
+			l.backup()
+			l.ignore()
+			return true
+		}
+	}
+}
+
+// lexInlineTableKey scans the start of an inline table entry after '{'
+// or ',', or the end of the table.
+func lexInlineTableKey(l *lexer) stateFn {
+	if !lexInlineSpace(l) {
+		return nil
+	}
+	switch r := l.next(); {
+	case r == '}':
+		if l.prev == tokenInlineTableSep && !l.require(TOML11, "trailing comma in inline table") {
+			return nil
+		}
+		l.nesting = l.nesting[:len(l.nesting)-1]
+		l.emit(tokenInlineTableEnd)
+		return lexValueEnd
+	case isBareKey(r) || r == '"':
+		l.backup()
+		return lexKey
+	default:
+		l.backup()
+		return l.errorf("expected key in inline table, found %s", describe(r))
+	}
+}
+
+// lexInlineTableNext scans what follows a value in an inline table.
+func lexInlineTableNext(l *lexer) stateFn {
+	if !lexInlineSpace(l) {
+		return nil
+	}
+	switch r := l.next(); {
+	case r == ',':
+		l.emit(tokenInlineTableSep)
+		return lexInlineTableKey
+	case r == '}':
+		l.nesting = l.nesting[:len(l.nesting)-1]
+		l.emit(tokenInlineTableEnd)
+		return lexValueEnd
+	default:
+		l.backup()
+		return l.errorf("expected ',' or '}' after inline table value, found %s", describe(r))
+	}
+}
+
+// lexLineEnd scans the rest of a line holding a value or a keygroup,
+// where only spaces and a comment may follow.
+func lexLineEnd(l *lexer) stateFn {
+	ignoreSpaces(l)
+	switch r := l.next(); {
+	case r == eof:
+		l.emit(tokenEOF)
+		return nil
+	case r == commentStart:
+		return lexComment(l, lexLineEnd)
+	case r == '\r' && l.peek() == '\n':
+		return lexLineEnd
+	case r == '\n':
+		l.ignore()
 		return lexStart
 	default:
-		return l.errorf("bad value %#U", r)
+		l.backup()
+		return l.errorf("expected newline after value, found %s", describe(r))
 	}
-	return nil
 }
 
 func lexString(l *lexer) stateFn {
@@ -327,14 +499,17 @@ Loop:
 		}
 	}
 	l.emit(tokenString)
-	return lexValue
+	return lexValueEnd
 }
 
 func lexNumberOrDatetime(l *lexer) stateFn {
-	i := int(l.pos)+4
-	if len(l.input) > i && l.input[i] == '-' {
+	s := l.input[l.pos:]
+	digits := func(s string) bool {
+		return strings.IndexFunc(s, func(r rune) bool { return !isDigit(r) }) < 0
+	}
+	if len(s) > 4 && s[4] == '-' && digits(s[:4]) || len(s) > 2 && s[2] == ':' && digits(s[:2]) {
 		return lexDatetime
-	} 
+	}
 
 	return lexNumber
 }
@@ -354,20 +529,30 @@ func lexNumber(l *lexer) stateFn {
 	}
 
 	l.emit(tokenNumber)
-	return lexValue
+	return lexValueEnd
 }
 
+// lexDatetime scans the characters of a date, time or datetime. A space
+// may separate the date from the time. The parser checks the format.
 func lexDatetime(l *lexer) stateFn {
-	for _, f := range datetimeFormat {
-		r := l.next()
-		if (f == '0' && isDigit(r)) || f == r {
-			// absorb.
-		} else {
-			return l.errorf("bad datetime %#U", r)
-		}
+	l.acceptRun(datetimeChars)
+	if rest := l.input[l.pos:]; l.pos-l.start == 10 && len(rest) > 1 && rest[0] == ' ' && isDigit(rune(rest[1])) {
+		l.next()
+		l.acceptRun(datetimeChars)
 	}
 	l.emit(tokenDatetime)
-	return lexValue
+	return lexValueEnd
+}
+
+// describe returns r quoted for an error message.
+func describe(r rune) string {
+	switch {
+	case r == eof:
+		return "end of file"
+	case isNewLine(r):
+		return "newline"
+	}
+	return fmt.Sprintf("%q", r)
 }
 
 func isSpace(r rune) bool {
@@ -375,7 +560,20 @@ func isSpace(r rune) bool {
 }
 
 func isDash(r rune) bool {
-  return r == '-' 
+	return r == '-'
+}
+
+// isDashLine reports whether s starts with a line holding only dashes,
+// optionally followed by spaces and a comment.
+func isDashLine(s string) bool {
+	s = strings.TrimLeft(s, "-")
+	s = strings.TrimLeft(s, " \t")
+	return s == "" || s[0] == '\n' || s[0] == '\r' || s[0] == commentStart
+}
+
+// isBareKey reports whether r may appear in a bare key.
+func isBareKey(r rune) bool {
+	return isAlphaNumeric(r) || isDash(r)
 }
 
 func isNewLine(r rune) bool {
@@ -29,11 +29,13 @@ arrs = [
 
 Since I am an asshole`
 
-
 func TestLex(t *testing.T) {
-	l := lex(doc)
+	l := lex(doc, Legacy)
 	for {
-		c := <- l.tokens
+		c := l.nextToken()
+		if c.typ == tokenError {
+			t.Fatal(c)
+		}
 		if c.typ == tokenEOF {
 			//pd(c)
 			break
@@ -41,3 +43,64 @@ func TestLex(t *testing.T) {
 		//pd(c)
 	}
 }
+
+// lexError returns the message of the first error token for input, or "".
+func lexError(input string, dialect Dialect) string {
+	l := lex(input, dialect)
+	for {
+		switch c := l.nextToken(); c.typ {
+		case tokenEOF:
+			return ""
+		case tokenError:
+			return c.val
+		}
+	}
+}
+
+func TestLexValueTermination(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"a = true\nb = false # no\n", ""},
+		{"a = [true, false]\n", ""},
+		{"a = [\n  1, # one\n  2,\n]\n", ""},
+		{"a=1\r\nb=2", ""},
+		{"[x] # table\nb = 2\n", ""},
+		{"a = truexyz\n", `bad value "truexyz"`},
+		{"a = true1\n", `bad value "true1"`},
+		{"a = 1 b = 2\n", "expected newline after value, found 'b'"},
+		{"a = \"x\"y\n", "expected newline after value, found 'y'"},
+		{"a = true,\n", "expected newline after value, found ','"},
+		{"a = [1]]\n", "expected newline after value, found ']'"},
+		{"[x] y = 1\n", "expected newline after value, found 'y'"},
+		{"a = [\"x\"1]\n", "expected ',' or ']' after array element, found '1'"},
+		{"a =\nb = 1\n", "expected value, found newline"},
+		{"a = # none\n", "expected value, found '#'"},
+	}
+	for _, tt := range tests {
+		if err := lexError(tt.input, Dialect{}); err != tt.err {
+			t.Errorf("%q: got error %q, want %q", tt.input, err, tt.err)
+		}
+	}
+}
+
+func TestLexDialect(t *testing.T) {
+	tests := []struct {
+		input   string
+		dialect Dialect
+		err     string
+	}{
+		{"a: 1\n", Dialect{}, "bad key seperator U+003A ':', want U+003D '='"},
+		{"a: 1\n", Dialect{ColonSeparator: true}, ""},
+		{"---\na = 1\n", Dialect{}, "bad keyname U+000A"},
+		{"---\na = 1\n---\nnot toml\n", Dialect{DashSeparators: true}, ""},
+		{"-a-b- = 1\n[x-y.z]\n", Dialect{}, ""},
+		{"-a = 1\n", Dialect{DashSeparators: true}, ""},
+	}
+	for _, tt := range tests {
+		if err := lexError(tt.input, tt.dialect); err != tt.err {
+			t.Errorf("%q: got error %q, want %q", tt.input, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package toml
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// A Loader decodes a stack of TOML documents, such as a system file, a
+// user file and a local file, into one value. Each layer overrides the
+// layers before it: tables are merged key by key and other values,
+// arrays included, are replaced. The arrays of struct fields tagged
+// `toml:"hosts,append"` are appended to instead.
+//
+// The options set on the embedded Decoder apply to every layer. Defaults
+// are set once, before the first layer, and required keys and validation
+// are checked once, on the merged value.
+type Loader struct {
+	Decoder
+	layers  []layer
+	origins map[string]Origin
+}
+
+// A layer is a document added to a Loader.
+type layer struct {
+	name string
+	data string
+}
+
+// An Origin tells which layer set a value, and where.
+type Origin struct {
+	Name string // name of the layer: the file path for AddFile
+	Line int    // line of the key, or of the header of a table
+}
+
+func (o Origin) String() string {
+	return fmt.Sprintf("%s:%d", o.Name, o.Line)
+}
+
+// NewLoader returns a new loader without layers.
+func NewLoader() *Loader {
+	return &Loader{}
+}
+
+// Add adds the document data as the next layer. name identifies the
+// layer in errors and origins.
+func (l *Loader) Add(name string, data []byte) {
+	l.layers = append(l.layers, layer{name, string(data)})
+}
+
+// AddFile adds the TOML file at path as the next layer. A file that does
+// not exist is skipped, so optional files can be added too.
+func (l *Loader) AddFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	l.Add(path, data)
+	return nil
+}
+
+// Decode decodes the layers in order into the value pointed to by v.
+// Errors in a layer are prefixed with its name.
+func (l *Loader) Decode(v interface{}) (err error) {
+	defer decodeRecover(&err)
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("toml: decode target must be a non-nil pointer, not %s", reflect.TypeOf(v))
+	}
+	if err := l.generic.check(); err != nil {
+		return err
+	}
+
+	m := &merge{
+		origins:   make(map[string]Origin),
+		locations: make(map[string]location),
+	}
+	d := l.newDecode(nil)
+	d.defaults(rv.Elem())
+	for _, layer := range l.layers {
+		if err := l.load(rv.Elem(), layer, m); err != nil {
+			return fmt.Errorf("%s: %w", layer.name, err)
+		}
+	}
+	l.origins = m.origins
+
+	d.locations = m.locations
+	return d.check(rv.Elem())
+}
+
+// load decodes layer and merges it into v.
+func (l *Loader) load(v reflect.Value, layer layer, m *merge) (err error) {
+	defer decodeRecover(&err)
+
+	tree, err := ParseDialect(layer.data, l.dialect)
+	if err != nil {
+		return err
+	}
+	d := l.newDecode(tree)
+	src := reflect.New(v.Type()).Elem()
+	d.top(src, tree.Root)
+	if d.errs != nil && len(*d.errs) > 0 {
+		return *d.errs
+	}
+
+	m.d, m.name = d, layer.name
+	m.value(v, src, false)
+	return nil
+}
+
+// Origins returns where the value of each key set by the layers last
+// decoded was loaded from, by path with the keys of the struct fields:
+// servers[1].port. Tables are listed too, with the last layer declaring
+// them.
+func (l *Loader) Origins() map[string]Origin {
+	origins := make(map[string]Origin, len(l.origins))
+	for path, o := range l.origins {
+		origins[path] = o
+	}
+	return origins
+}
+
+// A merge merges the values decoded from the layers into one value.
+type merge struct {
+	d         *decode             // decoding of the current layer
+	name      string              // name of the current layer
+	path      []string            // path of the current value, with canonical keys
+	origins   map[string]Origin   // origins of the merged values, by path
+	locations map[string]location // locations of the merged values, by path
+}
+
+// value merges src, decoded from the current layer, into dst. Tables are
+// merged, and other values replace dst or, for slices if appending, are
+// appended to it.
+func (m *merge) value(dst, src reflect.Value, appending bool) {
+	switch {
+	case dst.Kind() == reflect.Ptr && !src.IsNil():
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+			m.d.defaults(dst.Elem())
+		}
+		m.value(dst.Elem(), src.Elem(), appending)
+	case dst.Type() == orderedMapType:
+		m.table()
+		dm := dst.Addr().Interface().(*OrderedMap)
+		src.Addr().Interface().(*OrderedMap).Range(func(key string, value interface{}) bool {
+			elem := reflect.New(emptyInterfaceType).Elem()
+			if old, ok := dm.Get(key); ok {
+				elem.Set(reflect.ValueOf(old))
+			}
+			m.path = append(m.path, key)
+			m.value(elem, reflect.ValueOf(&value).Elem(), false)
+			m.path = m.path[:len(m.path)-1]
+			dm.Set(key, elem.Interface())
+			return true
+		})
+	case dst.Kind() == reflect.Struct && isTable(dst):
+		m.table()
+		for _, f := range typeFields(dst.Type(), m.d.naming) {
+			m.path = append(m.path, f.name)
+			if _, ok := m.d.locations[pathString(m.path)]; ok {
+				m.value(dst.Field(f.index), src.Field(f.index), f.append)
+			}
+			m.path = m.path[:len(m.path)-1]
+		}
+	case dst.Kind() == reflect.Map && !src.IsNil():
+		m.table()
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(dst.Type()))
+		}
+		e := &encode{}
+		for _, k := range src.MapKeys() {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if old := dst.MapIndex(k); old.IsValid() {
+				elem.Set(old)
+			} else {
+				m.d.defaults(elem)
+			}
+			m.path = append(m.path, e.mapKey(k))
+			m.value(elem, src.MapIndex(k), false)
+			m.path = m.path[:len(m.path)-1]
+			dst.SetMapIndex(k, elem)
+		}
+	case (dst.Kind() == reflect.Ptr || dst.Kind() == reflect.Map) && src.IsNil():
+		// An empty table leaves the table of the layers before as it is.
+		m.table()
+	case isGenericTable(dst) && isGenericTable(src) && dst.Elem().Type() == src.Elem().Type():
+		m.value(dst.Elem(), src.Elem(), false)
+	case dst.Kind() == reflect.Slice && appending && !isDeferred(dst.Type()):
+		n := dst.Len()
+		dst.Set(reflect.AppendSlice(dst, src))
+		m.appended(n)
+	default:
+		dst.Set(src)
+		m.replaced()
+	}
+}
+
+// isGenericTable reports whether v is an interface holding a table decoded
+// into a generic value.
+func isGenericTable(v reflect.Value) bool {
+	if v.Kind() != reflect.Interface || v.IsNil() {
+		return false
+	}
+	t := v.Elem().Type()
+	return t == genericMapType || t == reflect.PtrTo(orderedMapType)
+}
+
+// table records the current layer as the origin of the current table, if
+// it declares it.
+func (m *merge) table() {
+	key := pathString(m.path)
+	if loc, ok := m.d.locations[key]; ok {
+		m.set(key, loc)
+	}
+}
+
+// replaced records the current layer as the origin of the current value
+// and of all the values it holds.
+func (m *merge) replaced() {
+	prefix := pathString(m.path)
+	for key := range m.origins {
+		if hasPathPrefix(key, prefix) {
+			delete(m.origins, key)
+			delete(m.locations, key)
+		}
+	}
+	for key, loc := range m.d.locations {
+		if hasPathPrefix(key, prefix) {
+			m.set(key, loc)
+		}
+	}
+}
+
+// appended records the current layer as the origin of the current array
+// and of the elements it appended to it after the first n.
+func (m *merge) appended(n int) {
+	prefix := pathString(m.path)
+	for key, loc := range m.d.locations {
+		if key == prefix {
+			m.set(key, loc)
+			continue
+		}
+		var i int
+		if !strings.HasPrefix(key, prefix+"[") {
+			continue
+		}
+		rest := key[len(prefix):]
+		fmt.Sscanf(rest, "[%d]", &i)
+		rest = rest[len(fmt.Sprintf("[%d]", i)):]
+		m.set(fmt.Sprintf("%s[%d]%s", prefix, n+i, rest), loc)
+	}
+}
+
+// set records loc, in the current layer, as the location of the value
+// at path key.
+func (m *merge) set(key string, loc location) {
+	m.origins[key] = Origin{m.name, m.d.line(loc.pos)}
+	m.locations[key] = loc
+}
+
+// hasPathPrefix reports whether the path key is prefix or a path below
+// it. Every path is below the empty path.
+func hasPathPrefix(key, prefix string) bool {
+	if prefix == "" || key == prefix {
+		return true
+	}
+	return strings.HasPrefix(key, prefix) && (key[len(prefix)] == keyGroupSep || key[len(prefix)] == '[')
+}
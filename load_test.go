@@ -0,0 +1,191 @@
+package toml
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const systemLayer = `[server]
+host = "0.0.0.0"
+port = 80
+tags = ["a"]
+plugins = ["x"]
+
+[limits]
+rps = 10
+
+[[backends]]
+name = "one"
+
+[env]
+A = "1"
+`
+
+const userLayer = `[server]
+port = 8080
+tags = ["b", "c"]
+plugins = ["y"]
+
+[env]
+B = "2"
+
+[[backends]]
+name = "two"
+`
+
+type loadConfig struct {
+	Debug  bool
+	Server struct {
+		Host    string `toml:"host,required"`
+		Port    int
+		Timeout time.Duration `default:"30s"`
+		Tags    []string
+		Plugins []string `toml:"plugins,append"`
+	}
+	Limits *struct {
+		RPS   int `toml:"rps"`
+		Burst int `default:"5"`
+	}
+	Backends []struct {
+		Name string
+	} `toml:"backends,append"`
+	Env map[string]string
+}
+
+func TestLoader(t *testing.T) {
+	dir := t.TempDir()
+	system := filepath.Join(dir, "system.toml")
+	if err := os.WriteFile(system, []byte(systemLayer), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewLoader()
+	l.Strict()
+	for _, path := range []string{system, filepath.Join(dir, "missing.toml")} {
+		if err := l.AddFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Add("user.toml", []byte(userLayer))
+	l.Add("local.toml", []byte("debug = true\n"))
+
+	var c loadConfig
+	if err := l.Decode(&c); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Debug || c.Server.Host != "0.0.0.0" || c.Server.Port != 8080 || c.Server.Timeout != 30*time.Second {
+		t.Errorf("server %+v, debug %v", c.Server, c.Debug)
+	}
+	if !reflect.DeepEqual(c.Server.Tags, []string{"b", "c"}) || !reflect.DeepEqual(c.Server.Plugins, []string{"x", "y"}) {
+		t.Errorf("tags %q, plugins %q", c.Server.Tags, c.Server.Plugins)
+	}
+	if c.Limits == nil || c.Limits.RPS != 10 || c.Limits.Burst != 5 {
+		t.Errorf("limits %+v", c.Limits)
+	}
+	if len(c.Backends) != 2 || c.Backends[0].Name != "one" || c.Backends[1].Name != "two" {
+		t.Errorf("backends %+v", c.Backends)
+	}
+	if !reflect.DeepEqual(c.Env, map[string]string{"A": "1", "B": "2"}) {
+		t.Errorf("env %v", c.Env)
+	}
+
+	origins := l.Origins()
+	want := map[string]Origin{
+		"Debug":             {"local.toml", 1},
+		"Server":            {"user.toml", 1},
+		"Server.host":       {system, 2},
+		"Server.Port":       {"user.toml", 2},
+		"Server.Tags":       {"user.toml", 3},
+		"Server.Tags[1]":    {"user.toml", 3},
+		"Server.plugins[0]": {system, 5},
+		"Server.plugins[1]": {"user.toml", 4},
+		"Limits.rps":        {system, 8},
+		"backends[0].Name":  {system, 11},
+		"backends[1]":       {"user.toml", 9},
+		"backends[1].Name":  {"user.toml", 10},
+		"Env.A":             {system, 14},
+		"Env.B":             {"user.toml", 7},
+	}
+	for path, o := range want {
+		if origins[path] != o {
+			t.Errorf("origin of %s = %v, want %v", path, origins[path], o)
+		}
+	}
+	for _, path := range []string{"Server.Tags[2]", "Server.Timeout", "Limits.Burst"} {
+		if o, ok := origins[path]; ok {
+			t.Errorf("origin of %s = %v, want none", path, o)
+		}
+	}
+}
+
+func TestLoaderGeneric(t *testing.T) {
+	l := NewLoader()
+	l.Add("a", []byte("x = 1\n[t]\na = [1]\n[t.u]\nb = 2\n"))
+	l.Add("b", []byte("[t]\na = [2, 3]\n[t.u]\nc = 3\n"))
+	var v map[string]interface{}
+	if err := l.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"x": int64(1),
+		"t": map[string]interface{}{
+			"a": []interface{}{int64(2), int64(3)},
+			"u": map[string]interface{}{"b": int64(2), "c": int64(3)},
+		},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %v, want %v", v, want)
+	}
+	if o := l.Origins()["t.u.b"]; o != (Origin{"a", 5}) {
+		t.Errorf("origin of t.u.b = %v", o)
+	}
+}
+
+func TestLoaderEmptyTables(t *testing.T) {
+	l := NewLoader()
+	l.Add("a", []byte("[m]\na = 1\n[p]\nb = 2\n"))
+	l.Add("b", []byte("[m]\n[p]\n"))
+	var v struct {
+		M map[string]int
+		P *struct{ B int }
+	}
+	if err := l.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v.M, map[string]int{"a": 1}) || v.P == nil || v.P.B != 2 {
+		t.Errorf("got %v, %+v", v.M, v.P)
+	}
+	origins := l.Origins()
+	if origins["M"] != (Origin{"b", 1}) || origins["M.a"] != (Origin{"a", 2}) || origins["P.B"] != (Origin{"a", 4}) {
+		t.Errorf("origins %v", origins)
+	}
+}
+
+func TestLoaderErrors(t *testing.T) {
+	l := NewLoader()
+	l.Add("system.toml", []byte("[server]\nport = 80\n"))
+	l.Add("user.toml", []byte("[server]\nport = \"x\"\n"))
+	var c loadConfig
+	err := l.Decode(&c)
+	if err == nil || err.Error() != `user.toml: toml: server.port (line 2): cannot decode string "x" into int` {
+		t.Errorf("type error: %v", err)
+	}
+
+	l = NewLoader()
+	l.Add("system.toml", []byte("[server]\nport = 80\n"))
+	l.Add("user.toml", []byte("debug = true\n"))
+	err = l.Decode(&c)
+	if err == nil || err.Error() != "toml: server: missing required key host" {
+		t.Errorf("required: %v", err)
+	}
+
+	l = NewLoader()
+	l.Add("bad.toml", []byte("[server]\nport = 80\nport = 81\n"))
+	if err := l.Decode(&c); err == nil || !strings.HasPrefix(err.Error(), "bad.toml: 3: duplicate key") {
+		t.Errorf("syntax error: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package toml
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A Date is a local date without a time of day or time zone: 1979-05-27.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+func dateOf(t time.Time) Date {
+	return Date{t.Year(), t.Month(), t.Day()}
+}
+
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, int(d.Month), d.Day)
+}
+
+// In returns the start of day d in loc.
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// A TimeOfDay is a local time of day without a date or time zone: 07:32:00.
+type TimeOfDay struct {
+	Hour       int
+	Minute     int
+	Second     int
+	Nanosecond int
+}
+
+func timeOfDayOf(t time.Time) TimeOfDay {
+	return TimeOfDay{t.Hour(), t.Minute(), t.Second(), t.Nanosecond()}
+}
+
+func (t TimeOfDay) String() string {
+	s := fmt.Sprintf("%02d:%02d:%02d", t.Hour, t.Minute, t.Second)
+	if t.Nanosecond != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%09d", t.Nanosecond), "0")
+	}
+	return s
+}
+
+// A DateTime is a local date and time of day without a time zone:
+// 1979-05-27T07:32:00.
+type DateTime struct {
+	Date Date
+	Time TimeOfDay
+}
+
+func dateTimeOf(t time.Time) DateTime {
+	return DateTime{dateOf(t), timeOfDayOf(t)}
+}
+
+func (dt DateTime) String() string {
+	return dt.Date.String() + "T" + dt.Time.String()
+}
+
+// In returns the time dt in loc.
+func (dt DateTime) In(loc *time.Location) time.Time {
+	d, t := dt.Date, dt.Time
+	return time.Date(d.Year, d.Month, d.Day, t.Hour, t.Minute, t.Second, t.Nanosecond, loc)
+}
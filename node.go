@@ -1,17 +1,17 @@
 package toml
 
 import (
-	"time"
-	"strconv"
+	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
-	"bytes"
+	"time"
 )
 
 type Node interface {
 	Type() NodeType
 	String() string
-	//Copy() Node
+	Copy() Node
 	Position() Pos // byte position of start of node in full original input string
 	unexported()
 }
@@ -21,16 +21,17 @@ type NodeType int
 type Pos int
 
 const (
-	NodeList          NodeType = iota
+	NodeList NodeType = iota
 	NodeEntryGroup
 	NodeKeyGroup
 	NodeEntry
-	NodeKey                           // Key name
-	NodeBool                          // A boolean constant.
-	NodeString                        // A string constant.
-	NodeNumber                        // A number constant.
-	NodeDatetime                      // A datetime constant.
-	NodeArray                         
+	NodeKey      // Key name
+	NodeBool     // A boolean constant.
+	NodeString   // A string constant.
+	NodeNumber   // A number constant.
+	NodeDatetime // A datetime constant.
+	NodeArray
+	NodeInlineTable // An inline table: {key = value}.
 )
 
 func (t NodeType) Type() NodeType {
@@ -60,6 +61,21 @@ func (l *ListNode) append(n Node) {
 	l.Nodes = append(l.Nodes, n)
 }
 
+func (l *ListNode) CopyList() *ListNode {
+	if l == nil {
+		return l
+	}
+	n := newList(l.Pos)
+	for _, elem := range l.Nodes {
+		n.append(elem.Copy())
+	}
+	return n
+}
+
+func (l *ListNode) Copy() Node {
+	return l.CopyList()
+}
+
 func (l ListNode) String() string {
 	b := new(bytes.Buffer)
 	for _, n := range l.Nodes {
@@ -71,14 +87,18 @@ func (l ListNode) String() string {
 type EntryGroupNode struct {
 	NodeType
 	Pos
-	KeyGroup   *KeyGroupNode
-	Entries    *ListNode
+	KeyGroup *KeyGroupNode
+	Entries  *ListNode
 }
 
 func newEntryGroup(pos Pos, keyGroup *KeyGroupNode, entries *ListNode) *EntryGroupNode {
 	return &EntryGroupNode{NodeType: NodeEntryGroup, Pos: pos, KeyGroup: keyGroup, Entries: entries}
 }
 
+func (g *EntryGroupNode) Copy() Node {
+	return newEntryGroup(g.Pos, g.KeyGroup.Copy().(*KeyGroupNode), g.Entries.CopyList())
+}
+
 func (g EntryGroupNode) String() string {
 	entries := []string{}
 	for _, e := range g.Entries.Nodes {
@@ -90,12 +110,17 @@ func (g EntryGroupNode) String() string {
 type KeyGroupNode struct {
 	NodeType
 	Pos
-	Keys   *ListNode
-	Text   string
+	Keys  *ListNode
+	Text  string
+	Array bool // [[keys]]: the header adds a table to an array of tables.
+}
+
+func newKeyGroup(pos Pos, keys *ListNode, text string, array bool) *KeyGroupNode {
+	return &KeyGroupNode{NodeType: NodeKeyGroup, Pos: pos, Keys: keys, Text: text, Array: array}
 }
 
-func newKeyGroup(pos Pos, keys *ListNode, text string) *KeyGroupNode {
-	return &KeyGroupNode{NodeType: NodeKeyGroup, Pos: pos, Keys: keys, Text: text}
+func (g *KeyGroupNode) Copy() Node {
+	return newKeyGroup(g.Pos, g.Keys.CopyList(), g.Text, g.Array)
 }
 
 func (g KeyGroupNode) String() string {
@@ -103,6 +128,9 @@ func (g KeyGroupNode) String() string {
 	for _, k := range g.Keys.Nodes {
 		keys = append(keys, k.String())
 	}
+	if g.Array {
+		return fmt.Sprintf("[[%s]]", strings.Join(keys, "."))
+	}
 	return fmt.Sprintf("[%s]", strings.Join(keys, "."))
 }
 
@@ -117,12 +145,17 @@ func (g *KeyGroupNode) StringKeys() []string {
 type EntryNode struct {
 	NodeType
 	Pos
-	Key     *KeyNode
-	Value   Node
+	Key   *KeyNode
+	Value Node
+	End   Pos // byte position just past the end of the value.
+}
+
+func newEntry(pos Pos, key *KeyNode, value Node, end Pos) *EntryNode {
+	return &EntryNode{NodeType: NodeEntry, Pos: pos, Key: key, Value: value, End: end}
 }
 
-func newEntry(pos Pos, key *KeyNode, value Node) *EntryNode {
-	return &EntryNode{NodeType: NodeEntry, Pos: pos, Key: key, Value: value}
+func (e *EntryNode) Copy() Node {
+	return newEntry(e.Pos, e.Key.Copy().(*KeyNode), e.Value.Copy(), e.End)
 }
 
 func (e EntryNode) String() string {
@@ -132,15 +165,19 @@ func (e EntryNode) String() string {
 type KeyNode struct {
 	NodeType
 	Pos
-	Key    string
+	Key string
 }
 
 func newKey(pos Pos, key string) *KeyNode {
 	return &KeyNode{NodeType: NodeKey, Pos: pos, Key: key}
 }
 
+func (k *KeyNode) Copy() Node {
+	return newKey(k.Pos, k.Key)
+}
+
 func (k KeyNode) String() string {
-	return k.Key
+	return keyText(k.Key)
 }
 
 type BoolNode struct {
@@ -153,6 +190,10 @@ func newBool(pos Pos, true bool) *BoolNode {
 	return &BoolNode{NodeType: NodeBool, Pos: pos, True: true}
 }
 
+func (b *BoolNode) Copy() Node {
+	return newBool(b.Pos, b.True)
+}
+
 func (b BoolNode) String() string {
 	if b.True {
 		return "true"
@@ -163,14 +204,18 @@ func (b BoolNode) String() string {
 type StringNode struct {
 	NodeType
 	Pos
-	Text  string       // The string, after quote processing.
-	Quoted string      // The original text of the string, with quotes. 
+	Text   string // The string, after quote processing.
+	Quoted string // The original text of the string, with quotes.
 }
 
 func newString(pos Pos, text, orig string) *StringNode {
 	return &StringNode{NodeType: NodeString, Pos: pos, Text: text, Quoted: orig}
 }
 
+func (s *StringNode) Copy() Node {
+	return newString(s.Pos, s.Text, s.Quoted)
+}
+
 func (s StringNode) String() string {
 	return s.Quoted
 }
@@ -178,58 +223,89 @@ func (s StringNode) String() string {
 type NumberNode struct {
 	NodeType
 	Pos
-	IsInt      bool       // Number has an integral value.
-	IsFloat    bool       // Number has a floating-point value.
-	Int        int64      // The signed integer value.
-	Float      float64    // The floating-point value.
-	Text       string     // The original textual representation from the input.
+	IsInt    bool    // Number has an integral value.
+	IsFloat  bool    // Number has a floating-point value.
+	Overflow bool    // The value is out of range of Int or Float; Text holds it exactly.
+	Int      int64   // The signed integer value.
+	Float    float64 // The floating-point value.
+	Text     string  // The original textual representation from the input.
 }
 
 func newNumber(pos Pos, text string) (*NumberNode, error) {
 	n := &NumberNode{NodeType: NodeNumber, Pos: pos, Text: text}
-	i, err := strconv.ParseInt(text, 0, 64)
-	if err == nil {
-		n.IsInt = true
-		n.Int = i
-		return n, nil
-	} 
+	if !strings.ContainsAny(text, ".eE") {
+		i, err := strconv.ParseInt(text, 10, 64)
+		if err == nil || err.(*strconv.NumError).Err == strconv.ErrRange {
+			n.IsInt = true
+			n.Int = i
+			n.Overflow = err != nil
+			return n, nil
+		}
+	}
 
 	f, err := strconv.ParseFloat(text, 64)
-	if err == nil {
+	if err == nil || err.(*strconv.NumError).Err == strconv.ErrRange {
 		n.IsFloat = true
 		n.Float = f
+		n.Overflow = err != nil
 		return n, nil
 	}
 
 	return nil, fmt.Errorf("illegal number syntax: %q", text)
 }
 
+func (n *NumberNode) Copy() Node {
+	nn := new(NumberNode)
+	*nn = *n
+	return nn
+}
+
 func (n NumberNode) String() string {
 	return n.Text
 }
 
+// A DatetimeKind tells which parts of a datetime were given.
+type DatetimeKind int
+
+const (
+	OffsetDatetime DatetimeKind = iota // Date, time and offset.
+	LocalDatetime                      // Date and time.
+	LocalDate                          // Date only.
+	LocalTime                          // Time only.
+)
+
 type DatetimeNode struct {
 	NodeType
 	Pos
-	Time time.Time
+	Time time.Time // The datetime; local kinds are in time.Local.
+	Kind DatetimeKind
+	Text string // The original textual representation from the input.
+}
+
+func newDatetime(pos Pos, time time.Time, kind DatetimeKind, text string) *DatetimeNode {
+	return &DatetimeNode{NodeType: NodeDatetime, Pos: pos, Time: time, Kind: kind, Text: text}
 }
 
-func newDatetime(pos Pos, time time.Time) *DatetimeNode { 
-	return &DatetimeNode{NodeType: NodeDatetime, Pos: pos, Time: time}
+func (t *DatetimeNode) Copy() Node {
+	return newDatetime(t.Pos, t.Time, t.Kind, t.Text)
 }
 
 func (t DatetimeNode) String() string {
-	return t.Time.Format(time.RFC3339)
+	return t.Text
 }
 
-type ArrayNode struct { 
+type ArrayNode struct {
 	NodeType
 	Pos
 	Array *ListNode
 }
 
 func newArray(pos Pos, array *ListNode) *ArrayNode {
-	return &ArrayNode{NodeType: NodeArray, Pos: pos, Array: array} 
+	return &ArrayNode{NodeType: NodeArray, Pos: pos, Array: array}
+}
+
+func (a *ArrayNode) Copy() Node {
+	return newArray(a.Pos, a.Array.CopyList())
 }
 
 func (a ArrayNode) String() string {
@@ -239,3 +315,25 @@ func (a ArrayNode) String() string {
 	}
 	return fmt.Sprintf("[%s]", strings.Join(values, ", "))
 }
+
+type InlineTableNode struct {
+	NodeType
+	Pos
+	Entries *ListNode // The entries, in lexical order.
+}
+
+func newInlineTable(pos Pos, entries *ListNode) *InlineTableNode {
+	return &InlineTableNode{NodeType: NodeInlineTable, Pos: pos, Entries: entries}
+}
+
+func (t *InlineTableNode) Copy() Node {
+	return newInlineTable(t.Pos, t.Entries.CopyList())
+}
+
+func (t InlineTableNode) String() string {
+	entries := []string{}
+	for _, e := range t.Entries.Nodes {
+		entries = append(entries, e.String())
+	}
+	return fmt.Sprintf("{%s}", strings.Join(entries, ", "))
+}
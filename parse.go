@@ -1,32 +1,77 @@
 package toml
 
 import (
-	"time"
-	"strconv"
+	"fmt"
 	"runtime"
+	"strconv"
 	"strings"
-	"fmt"
+	"time"
+	"unicode/utf8"
 )
 
 type Tree struct {
 	Root      *ListNode // top-level root of the tree.
 	text      string
 	lex       *lexer
-	token     [3]token   // three-token lookahead for parser.
+	token     [3]token // three-token lookahead for parser.
 	peekCount int
+	lastEnd   Pos // end of the most recently parsed value.
+	dialect   Dialect
+	keys      map[string]keyKind // what the paths defined so far are.
+	arrays    map[string]int     // lengths of the arrays of tables.
+	table     []string           // path of the current table.
+
+	// Version is the lowest TOML version whose syntax the text conforms
+	// to, at most the version of the dialect it was parsed with.
+	Version Version
 }
 
+// Parse parses text as standard TOML.
 func Parse(text string) (tree *Tree, err error) {
+	return ParseDialect(text, Dialect{})
+}
+
+// ParseDialect parses text with the extensions enabled in dialect.
+func ParseDialect(text string, dialect Dialect) (tree *Tree, err error) {
 	defer parseRecover(&err)
 
 	t := &Tree{}
 	t.text = text
-	t.lex = lex(text)
+	t.dialect = dialect
+	t.lex = lex(text, dialect)
 	t.parse()
+	if t.lex.version > t.Version {
+		t.Version = t.lex.version
+	}
 
 	return t, nil
 }
 
+// reparse replaces the text of t and rebuilds its nodes.
+func (t *Tree) reparse(text string) error {
+	nt, err := ParseDialect(text, t.dialect)
+	if err != nil {
+		return err
+	}
+	t.Root = nt.Root
+	t.text = nt.text
+	t.Version = nt.Version
+	return nil
+}
+
+// Copy returns a copy of the Tree. Any parsing state is discarded.
+func (t *Tree) Copy() *Tree {
+	if t == nil {
+		return nil
+	}
+	return &Tree{
+		Root:    t.Root.CopyList(),
+		text:    t.text,
+		dialect: t.dialect,
+		Version: t.Version,
+	}
+}
+
 // recover is the handler that turns panics into returns from the top level of Parse.
 func parseRecover(errp *error) {
 	e := recover()
@@ -128,9 +173,18 @@ func (t *Tree) ErrorContext(n Node) (location, context string) {
 
 // errorf formats the error and terminates processing.
 func (t *Tree) errorf(format string, args ...interface{}) {
+	t.failf(t.lex.lineNumber(), ErrSyntax, format, args...)
+}
+
+// failf terminates processing with an error of category kind on line.
+func (t *Tree) failf(line int, kind error, format string, args ...interface{}) {
 	t.Root = nil
-	format = fmt.Sprintf("%d: syntax error: %s", t.lex.lineNumber(), format)
-	panic(fmt.Errorf(format, args...))
+	panic(&ParseError{Line: line, Message: fmt.Sprintf(format, args...), Err: kind})
+}
+
+// line returns the line number of pos.
+func (t *Tree) line(pos Pos) int {
+	return 1 + strings.Count(t.text[:pos], "\n")
 }
 
 // error terminates processing.
@@ -158,11 +212,27 @@ func (t *Tree) expectOneOf(expected1, expected2 tokenType, context string) token
 
 // unexpected complains about the token and terminates processing.
 func (t *Tree) unexpected(tok token, context string) {
+	if tok.typ == tokenError {
+		t.errorf("%s", tok.val)
+	}
 	t.errorf("unexpected %s in %s", tok, context)
 }
 
+// require records that the text needs TOML version v for feature, or
+// terminates processing if the dialect accepts an earlier version only.
+func (t *Tree) require(v Version, feature string) {
+	if t.dialect.Version < v {
+		t.errorf("%s requires TOML %s", feature, v)
+	}
+	if t.Version < v {
+		t.Version = v
+	}
+}
+
 func (t *Tree) parse() Node {
 	t.Root = newList(t.peek().pos)
+	t.keys = make(map[string]keyKind)
+	t.arrays = make(map[string]int)
 
 	for t.peek().typ != tokenEOF {
 		n := t.top()
@@ -182,7 +252,9 @@ func (t *Tree) top() Node {
 	case tokenKeyGroup:
 		return t.entryGroup()
 	case tokenKey:
-		return t.entry()
+		n := t.entry()
+		t.define(n.(*EntryNode).Key)
+		return n
 	default:
 		t.errorf("unexpected %q", tok.val)
 		return nil
@@ -191,88 +263,283 @@ func (t *Tree) top() Node {
 }
 
 // [keygroup]
-//   ...
+//
+//	...
 func (t *Tree) entryGroup() Node {
 	token := t.nextNonSpace()
-	keyGroup := parseKeyGroup(token)
+	keyGroup := t.parseKeyGroup(token)
+	t.declare(keyGroup)
 	entries := newList(t.peek().pos)
 
 Loop:
 	for {
 		switch tok := t.peekNonSpace(); tok.typ {
 		case tokenKey:
-			entries.append(t.entry())
+			n := t.entry()
+			t.define(n.(*EntryNode).Key)
+			entries.append(n)
 		default:
 			break Loop
 		}
 	}
 
-	return newEntryGroup(token.pos, keyGroup, entries) 
+	return newEntryGroup(token.pos, keyGroup, entries)
 }
 
-// "[foo.bar]"
-func parseKeyGroup(tok token) *KeyGroupNode {
+// "[foo.bar]" or "[[foo.bar]]"
+func (t *Tree) parseKeyGroup(tok token) *KeyGroupNode {
 	text := tok.val
-	name := text[1:len(text)-1]
-	keys := newList(tok.pos+Pos(1))
+	array := strings.HasPrefix(text, "[[")
+	n := 1
+	if array {
+		n = 2
+	}
+	name := text[n : len(text)-n]
+	keys := newList(tok.pos + Pos(n))
 
-	for _, v := range strings.Split(name, ".") {
-		keys.append(newKey(tok.pos+Pos(len(v)), v))
+	pos := tok.pos + Pos(n)
+	for _, v := range splitKeys(name) {
+		keys.append(t.key(pos, v))
+		pos += Pos(len(v) + 1)
 	}
 
-	return newKeyGroup(tok.pos, keys, text)
+	return newKeyGroup(tok.pos, keys, text, array)
+}
+
+// splitKeys splits the name of a keygroup at the dots outside quoted
+// keys.
+func splitKeys(name string) []string {
+	var keys []string
+	start, quoted := 0, false
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case c == keyGroupSep && !quoted:
+			keys = append(keys, name[start:i])
+			start = i + 1
+		}
+	}
+	return append(keys, name[start:])
+}
+
+// key returns the key written as text at pos, which is bare or quoted.
+func (t *Tree) key(pos Pos, text string) *KeyNode {
+	if strings.HasPrefix(text, `"`) {
+		return newKey(pos, t.unquote(text))
+	}
+	return newKey(pos, text)
+}
+
+// A keyKind is what a path of the document is defined as.
+type keyKind int
+
+const (
+	implicitTable keyKind = iota // a table only named in headers of subtables.
+	explicitTable                // a table declared by a [table] header.
+	arrayOfTables
+	valueKey
+)
+
+// declare records the table declared by header g and makes it the
+// current table. It terminates processing if the table or one of its
+// parents is already defined as something else.
+func (t *Tree) declare(g *KeyGroupNode) {
+	keys := g.StringKeys()
+	path := []string{}
+	for i, k := range keys {
+		path = append(path, k)
+		name := pathString(path)
+		kind, ok := t.keys[name]
+		switch {
+		case ok && kind == valueKey:
+			t.failf(t.line(g.Pos), ErrTableRedefined, "%s is already defined as a value", name)
+		case i < len(keys)-1:
+			if !ok {
+				t.keys[name] = implicitTable
+			}
+		case g.Array:
+			if ok && kind != arrayOfTables {
+				t.failf(t.line(g.Pos), ErrTableRedefined, "table %s is already defined", name)
+			}
+			t.keys[name] = arrayOfTables
+			path = append(path, fmt.Sprintf("[%d]", t.arrays[name]))
+			t.arrays[name]++
+			continue
+		default:
+			if ok && kind != implicitTable && !(kind == explicitTable && t.dialect.DuplicateTables) {
+				t.failf(t.line(g.Pos), ErrTableRedefined, "table %s is already defined", name)
+			}
+			t.keys[name] = explicitTable
+		}
+		if n, ok := t.arrays[name]; ok {
+			path = append(path, fmt.Sprintf("[%d]", n-1))
+		}
+	}
+	t.table = path
+}
+
+// define records key of the current table, terminating processing if it
+// is already defined.
+func (t *Tree) define(key *KeyNode) {
+	name := pathString(append(t.table[:len(t.table):len(t.table)], key.Key))
+	if _, ok := t.keys[name]; ok {
+		t.failf(t.line(key.Pos), ErrDuplicateKey, "duplicate key %s", name)
+	}
+	t.keys[name] = valueKey
 }
 
 // key = value
 func (t *Tree) entry() Node {
 	tok := t.nextNonSpace()
-	key := newKey(tok.pos, tok.val)
+	key := t.key(tok.pos, tok.val)
 	//pd("entry %s", tok.val)
 	t.expect(tokenKeySep, "key seperator")
 
-	return newEntry(tok.pos, key, t.value())
+	value := t.value()
+	return newEntry(tok.pos, key, value, t.lastEnd)
 }
 
-// value: string, array, ... 
+// value: string, array, ...
 func (t *Tree) value() Node {
-	switch tok := t.nextNonSpace(); tok.typ {
+	tok := t.nextNonSpace()
+	t.lastEnd = tok.pos + Pos(len(tok.val))
+	switch tok.typ {
 	case tokenBool:
 		return newBool(tok.pos, tok.val == "true")
 	case tokenNumber:
 		v, err := newNumber(tok.pos, tok.val)
-		if err != nil { t.error(err) }
+		if err != nil {
+			t.error(err)
+		}
 		return v
 	case tokenString:
 		//pd("str %d %s", tok.typ, tok.val)
-		v, err := strconv.Unquote(tok.val)
-		if err != nil { t.error(err) }
-		return newString(tok.pos, v, tok.val)
+		return newString(tok.pos, t.unquote(tok.val), tok.val)
 	case tokenDatetime:
-		v, err := time.Parse(time.RFC3339, tok.val)
-		if err != nil { t.error(err) }
-		return newDatetime(tok.pos, v)
+		return t.datetime(tok)
 	case tokenArrayStart:
-		return t.array() 
+		return t.array(tok.pos)
+	case tokenInlineTableStart:
+		return t.inlineTable(tok.pos)
 	default:
-		t.errorf("unexpected %q in value", tok.val)
-		return nil
+		t.unexpected(tok, "value")
 	}
 	return nil
 }
 
+// unquote interprets the escape sequences of a quoted basic string.
+func (t *Tree) unquote(quoted string) string {
+	s := quoted[1 : len(quoted)-1]
+	b := make([]rune, 0, len(s))
+	for i := 0; i < len(s); {
+		r, w := utf8.DecodeRuneInString(s[i:])
+		i += w
+		if r != '\\' {
+			b = append(b, r)
+			continue
+		}
+		c := s[i]
+		i++
+		switch c {
+		case 'b':
+			r = '\b'
+		case 't':
+			r = '\t'
+		case 'n':
+			r = '\n'
+		case 'f':
+			r = '\f'
+		case 'r':
+			r = '\r'
+		case '"', '\\':
+			r = rune(c)
+		case 'e':
+			t.require(TOML11, `escape \e`)
+			r = 0x1b
+		case 'x', 'u', 'U':
+			n := 4
+			switch c {
+			case 'x':
+				t.require(TOML11, `escape \x`)
+				n = 2
+			case 'U':
+				n = 8
+			}
+			if len(s) < i+n {
+				t.errorf("short escape \\%c in %s", c, quoted)
+			}
+			v, err := strconv.ParseUint(s[i:i+n], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(v)) {
+				t.errorf("bad escape \\%s in %s", s[i-1:i+n], quoted)
+			}
+			i += n
+			r = rune(v)
+		default:
+			t.errorf("bad escape \\%c in %s", c, quoted)
+		}
+		b = append(b, r)
+	}
+	return string(b)
+}
+
+var datetimeFormats = []struct {
+	layout  string
+	kind    DatetimeKind
+	seconds bool
+}{
+	{"2006-01-02T15:04:05Z07:00", OffsetDatetime, true},
+	{"2006-01-02T15:04:05", LocalDatetime, true},
+	{"2006-01-02", LocalDate, true},
+	{"15:04:05", LocalTime, true},
+	{"2006-01-02T15:04Z07:00", OffsetDatetime, false},
+	{"2006-01-02T15:04", LocalDatetime, false},
+	{"15:04", LocalTime, false},
+}
+
+// 1979-05-27T07:32:00Z, 1979-05-27 07:32:00.999, 1979-05-27, 07:32:00 ...
+func (t *Tree) datetime(tok token) Node {
+	s := tok.val
+	if len(s) > 10 && (s[10] == ' ' || s[10] == 't') {
+		s = s[:10] + "T" + s[11:]
+	}
+	if strings.HasSuffix(s, "z") {
+		s = s[:len(s)-1] + "Z"
+	}
+	for _, f := range datetimeFormats {
+		v, err := time.ParseInLocation(f.layout, s, time.Local)
+		if err != nil {
+			continue
+		}
+		if !f.seconds {
+			t.require(TOML11, "datetime without seconds")
+		}
+		return newDatetime(tok.pos, v, f.kind, tok.val)
+	}
+	t.failf(t.line(tok.pos), ErrInvalidDatetime, "invalid datetime %q", tok.val)
+	return nil
+}
+
 // [1, 2]
-func (t *Tree) array() Node {
-	pos := t.peek().pos
+func (t *Tree) array(pos Pos) Node {
 	array := newList(pos)
 Loop:
 	for {
 		switch tok := t.peekNonSpace(); tok.typ {
 		case tokenArrayEnd:
 			t.nextNonSpace()
+			t.lastEnd = tok.pos + Pos(len(tok.val))
 			break Loop
 		default:
 			//pd("array %s", tok.val)
 			node := t.value()
+			if t.dialect.HomogeneousArrays && len(array.Nodes) > 0 {
+				if first := nodeKind(array.Nodes[0]); nodeKind(node) != first {
+					t.errorf("mixed types %s and %s in array", first, nodeKind(node))
+				}
+			}
 			if t.peekNonSpace().typ != tokenArrayEnd {
 				t.expect(tokenArraySep, "array")
 			}
@@ -282,3 +549,31 @@ Loop:
 
 	return newArray(pos, array)
 }
+
+// {a = 1, b = 2}
+func (t *Tree) inlineTable(pos Pos) Node {
+	entries := newList(pos)
+	keys := make(map[string]bool)
+	for {
+		tok := t.nextNonSpace()
+		switch tok.typ {
+		case tokenInlineTableEnd:
+			t.lastEnd = tok.pos + Pos(len(tok.val))
+			return newInlineTable(pos, entries)
+		case tokenKey:
+			t.backup()
+			n := t.entry()
+			key := n.(*EntryNode).Key
+			if keys[key.Key] {
+				t.failf(t.line(key.Pos), ErrDuplicateKey, "duplicate key %s in inline table", keyText(key.Key))
+			}
+			keys[key.Key] = true
+			entries.append(n)
+			if t.peekNonSpace().typ != tokenInlineTableEnd {
+				t.expect(tokenInlineTableSep, "inline table")
+			}
+		default:
+			t.unexpected(tok, "inline table")
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package toml
 
 import (
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -28,7 +32,7 @@ arrs = [
 `
 
 func TestParse(t *testing.T) {
-	_, e := Parse(doc2)
+	_, e := ParseDialect(doc2, Dialect{DuplicateTables: true})
 	if e != nil {
 		t.Fatal(e)
 	}
@@ -36,3 +40,139 @@ func TestParse(t *testing.T) {
 	//pd(v.Root)
 }
 
+func TestParseErrorLeavesNoGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		if _, e := Parse("a = [1 2]\nb = 3\n"); e == nil {
+			t.Fatal("expected a syntax error")
+		}
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines after failed parses, %d before", after, before)
+	}
+}
+
+func TestParseHomogeneousArrays(t *testing.T) {
+	doc := "a = [1, \"x\"]\nb = [[1], [\"x\"]]\n"
+	if _, e := Parse(doc); e != nil {
+		t.Errorf("standard: %s", e)
+	}
+	_, e := ParseDialect(doc, Dialect{HomogeneousArrays: true})
+	if e == nil || e.Error() != "1: syntax error: mixed types integer and string in array" {
+		t.Errorf("homogeneous: %v", e)
+	}
+	if _, e := ParseDialect("b = [[1], [\"x\"]]\n", Dialect{HomogeneousArrays: true}); e != nil {
+		t.Errorf("nested: %s", e)
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		doc string
+		err string // error parsing as TOML 1.0, "" if the doc is TOML 1.0.
+	}{
+		{"a = {x = 1, y = [1, 2], z = {}}\n", ""},
+		{"a = \"\\u00e9\\t\"\n", ""},
+		{"a = 1979-05-27T07:32:00.5-07:00\nb = 1979-05-27 07:32:00\nc = 1979-05-27\nd = 07:32:00\n", ""},
+		{"a = {x = 1,\n y = 2}\n", "1: syntax error: newline in inline table requires TOML 1.1"},
+		{"a = {x = 1, # one\n}\n", "1: syntax error: comment in inline table requires TOML 1.1"},
+		{"a = {x = 1, }\n", "1: syntax error: trailing comma in inline table requires TOML 1.1"},
+		{"a = \"\\e[0m\"\n", "1: syntax error: escape \\e requires TOML 1.1"},
+		{"a = \"\\x41\"\n", "1: syntax error: escape \\x requires TOML 1.1"},
+		{"a = 07:32\n", "1: syntax error: datetime without seconds requires TOML 1.1"},
+		{"a = 1979-05-27T07:32Z\n", "1: syntax error: datetime without seconds requires TOML 1.1"},
+	}
+	for _, tt := range tests {
+		_, e := Parse(tt.doc)
+		if tt.err == "" && e != nil || tt.err != "" && (e == nil || e.Error() != tt.err) {
+			t.Errorf("%q as 1.0: got error %v, want %q", tt.doc, e, tt.err)
+		}
+		tree, e := ParseDialect(tt.doc, Dialect{Version: TOML11})
+		if e != nil {
+			t.Errorf("%q as 1.1: %s", tt.doc, e)
+			continue
+		}
+		want := TOML10
+		if tt.err != "" {
+			want = TOML11
+		}
+		if tree.Version != want {
+			t.Errorf("%q: Version = %s, want %s", tt.doc, tree.Version, want)
+		}
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	tree, e := ParseDialect("s = \"\\x41\\e\\U0001F600\"\nd = 1979-05-27T07:32Z\nl = 1979-05-27\n", Dialect{Version: TOML11})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if s := tree.Get("s").(*StringNode).Text; s != "A\x1b\U0001F600" {
+		t.Errorf("s = %q", s)
+	}
+	if d := tree.Get("d").(*DatetimeNode); d.Kind != OffsetDatetime || d.Time.Minute() != 32 || d.String() != "1979-05-27T07:32Z" {
+		t.Errorf("d = %v", d)
+	}
+	if d := tree.Get("l").(*DatetimeNode); d.Kind != LocalDate || d.Time.Day() != 27 {
+		t.Errorf("l = %v", d)
+	}
+	for _, doc := range []string{"a = \"\\q\"\n", "a = \"\\uD800\"\n", "a = \"\\u12\"\n", "a = 1979-13-27\n"} {
+		if _, e := Parse(doc); e == nil {
+			t.Errorf("%q: expected error", doc)
+		}
+	}
+}
+
+func TestParseArrayOfTables(t *testing.T) {
+	tree, e := Parse("[[a.b]]\nx = 1\n[[a.b]]\n")
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, n := range tree.Root.Nodes {
+		if g := n.(*EntryGroupNode).KeyGroup; !g.Array || g.String() != "[[a.b]]" || !reflect.DeepEqual(g.StringKeys(), []string{"a", "b"}) {
+			t.Errorf("key group %v, Array = %v", g, g.Array)
+		}
+	}
+	if _, e := Parse("[[a]\n"); e == nil || !strings.Contains(e.Error(), "expected ']]'") {
+		t.Errorf("[[a]: %v", e)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		err  string
+		kind error
+	}{
+		{"a = 1\na = 2\n", "2: duplicate key a", ErrDuplicateKey},
+		{"[t]\nx = 1\n\nx = 2\n", "4: duplicate key t.x", ErrDuplicateKey},
+		{"a = {x = 1, x = 2}\n", "1: duplicate key x in inline table", ErrDuplicateKey},
+		{"[a.b]\n[a]\nb = 1\n", "3: duplicate key a.b", ErrDuplicateKey},
+		{"[owner]\n[owner]\n", "2: table owner is already defined", ErrTableRedefined},
+		{"a = 1\n[a]\n", "2: a is already defined as a value", ErrTableRedefined},
+		{"a = {}\n[a.b]\n", "2: a is already defined as a value", ErrTableRedefined},
+		{"[a]\n[[a]]\n", "2: table a is already defined", ErrTableRedefined},
+		{"[[a]]\n[a]\n", "2: table a is already defined", ErrTableRedefined},
+		{"a = 1979-13-27\n", `1: invalid datetime "1979-13-27"`, ErrInvalidDatetime},
+		{"a = [1 2]\n", `1: syntax error: unexpected "2" in array`, ErrSyntax},
+	}
+	for _, tt := range tests {
+		_, e := Parse(tt.doc)
+		if e == nil || e.Error() != tt.err || !errors.Is(e, tt.kind) {
+			t.Errorf("%q: got error %v, want %q (%v)", tt.doc, e, tt.err, tt.kind)
+		}
+	}
+
+	for _, doc := range []string{
+		"[a.b]\n[a]\nc = 1\n",
+		"[[a]]\nx = 1\n[[a]]\nx = 2\n[a.b]\nx = 3\n[[a]]\n[a.b]\n",
+		"[owner]\nx = 1\n",
+	} {
+		if _, e := Parse(doc); e != nil {
+			t.Errorf("%q: %v", doc, e)
+		}
+	}
+	if _, e := ParseDialect("[owner]\n[owner]\nx = 1\n", Legacy); e != nil {
+		t.Errorf("duplicate tables in the legacy dialect: %v", e)
+	}
+}
@@ -0,0 +1,195 @@
+package toml
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+)
+
+var (
+	primitiveType  = reflect.TypeOf(Primitive{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	jsonNumberType = reflect.TypeOf(json.Number(""))
+)
+
+// A Primitive holds a value whose decoding is deferred until its Go type
+// is known. Decoding into a Primitive stores the value without
+// interpreting it; PrimitiveDecode decodes it later.
+type Primitive struct {
+	// Node is the value. A table, whether written inline or as [table]
+	// sections, is an *InlineTableNode.
+	Node Node
+
+	// Source is the TOML text of the value: the value itself, the
+	// sections declaring a table, or the whole document.
+	Source string
+
+	d *decode // options and path of the decoding that stored the value
+}
+
+// PrimitiveDecode decodes p into the value pointed to by v, with the
+// options of the decoding that stored p.
+func PrimitiveDecode(p Primitive, v interface{}) (err error) {
+	d := &decode{}
+	if p.d != nil {
+		*d = *p.d
+		d.path = append([]string(nil), p.d.path...)
+		d.canon = append([]string(nil), p.d.canon...)
+		d.fields = append([]string(nil), p.d.fields...)
+		if d.errs != nil {
+			d.errs = &DecodeErrors{}
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			switch r.(type) {
+			case runtime.Error:
+				panic(r)
+			case skipValue:
+				err = *d.errs
+			default:
+				err = r.(error)
+			}
+		}
+	}()
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("toml: decode target must be a non-nil pointer, not %s", reflect.TypeOf(v))
+	}
+	if p.Node == nil {
+		return nil
+	}
+	d.defaults(rv.Elem())
+	d.value(rv.Elem(), p.Node)
+	return d.check(rv.Elem())
+}
+
+// isDeferred reports whether tables of type t are decoded whole, after
+// gathering all the sections declaring them: values stored undecoded and
+// interfaces of registered unions.
+func isDeferred(t reflect.Type) bool {
+	return t == primitiveType || t == rawMessageType || unionOf(t) != nil
+}
+
+// deferred decodes into v, whose type is deferred, the table at the
+// current path, gathering it from all the sections declaring it.
+// Sections met later for the same table are skipped.
+func (d *decode) deferred(v reflect.Value) {
+	key := pathString(d.path)
+	if d.done[key] {
+		return
+	}
+	if d.done == nil {
+		d.done = make(map[string]bool)
+	}
+	d.done[key] = true
+
+	table, source := d.table(d.path)
+	d.source = source
+	d.value(v, table)
+}
+
+// table returns the table or array of tables at path as an inline value,
+// with the text of the sections declaring it.
+func (d *decode) table(path []string) (Node, string) {
+	t := d.tree
+	var value Node
+	source := ""
+	for i, node := range t.Root.Nodes {
+		switch node := node.(type) {
+		case *EntryNode:
+			if len(path) == 0 {
+				value = d.subTable(&value, nil, node.Pos)
+				value.(*InlineTableNode).Entries.append(node)
+			}
+		case *EntryGroupNode:
+			groupPath := d.paths[node]
+			if !hasPrefix(groupPath, path) {
+				continue
+			}
+			sub := d.subTable(&value, groupPath[len(path):], node.Pos)
+			sub.Entries.Nodes = append(sub.Entries.Nodes, node.Entries.Nodes...)
+			source += t.text[t.lineStart(node.Position()):t.groupEnd(i)]
+		}
+	}
+	if value == nil {
+		value = newInlineTable(0, newList(0))
+	}
+	if len(path) == 0 {
+		source = t.text
+	}
+	return value, source
+}
+
+// subTable returns the inline table at path below *value, adding empty
+// tables and arrays of tables on the way. *value is created when nil.
+func (d *decode) subTable(value *Node, path []string, pos Pos) *InlineTableNode {
+	if *value == nil {
+		if len(path) > 0 && isIndex(path[0]) {
+			*value = newArray(pos, newList(pos))
+		} else {
+			*value = newInlineTable(pos, newList(pos))
+		}
+	}
+	if len(path) == 0 {
+		table, ok := (*value).(*InlineTableNode)
+		if !ok {
+			d.failf(ErrTableRedefined, "table conflicts with an earlier value")
+		}
+		return table
+	}
+	switch v := (*value).(type) {
+	case *ArrayNode:
+		if isIndex(path[0]) {
+			var i int
+			fmt.Sscanf(path[0], "[%d]", &i)
+			if i == len(v.Array.Nodes) {
+				v.Array.append(nil)
+			}
+			return d.subTable(&v.Array.Nodes[i], path[1:], pos)
+		}
+	case *InlineTableNode:
+		if !isIndex(path[0]) {
+			for _, n := range v.Entries.Nodes {
+				if e := n.(*EntryNode); e.Key.Key == path[0] {
+					return d.subTable(&e.Value, path[1:], pos)
+				}
+			}
+			e := newEntry(pos, newKey(pos, path[0]), nil, pos)
+			v.Entries.append(e)
+			return d.subTable(&e.Value, path[1:], pos)
+		}
+	}
+	d.failf(ErrTableRedefined, "table conflicts with an earlier value")
+	return nil
+}
+
+// primitive stores node in v, a Primitive or a json.RawMessage, without
+// decoding it into a Go type.
+func (d *decode) primitive(v reflect.Value, node Node) {
+	if v.Type() == primitiveType {
+		source := d.source
+		if source == "" {
+			source = node.String()
+		}
+		p := *d
+		p.path = append([]string(nil), d.path...)
+		p.canon = append([]string(nil), d.canon...)
+		p.fields = append([]string(nil), d.fields...)
+		p.done, p.source, p.names = nil, "", nil
+		v.Set(reflect.ValueOf(Primitive{Node: node, Source: source, d: &p}))
+		return
+	}
+
+	generic := *d
+	generic.generic = GenericTypes{}
+	value := reflect.New(emptyInterfaceType).Elem()
+	generic.value(value, node)
+	b, err := json.Marshal(value.Interface())
+	if err != nil {
+		d.failf(ErrInvalidValue, "%v", err)
+	}
+	v.SetBytes(b)
+}
@@ -0,0 +1,212 @@
+package toml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Queries.
+//
+// Paths are dotted key names as in a table header: server.host. A key
+// containing dots or spaces is quoted: servers."alpha.example".ip.
+
+// Has reports whether a key or table is defined at path.
+func (t *Tree) Has(path string) bool {
+	return t.Get(path) != nil
+}
+
+// Keys returns the names of the keys and sub-tables of the table at path,
+// in the order they first appear in the document. The empty path is the
+// root table.
+func (t *Tree) Keys(path string) ([]string, error) {
+	table := []string{}
+	if path != "" {
+		var err error
+		if table, err = splitPath(path); err != nil {
+			return nil, err
+		}
+		switch n := t.Get(path).(type) {
+		case *EntryGroupNode:
+		case *InlineTableNode:
+			keys := []string{}
+			for _, e := range n.Entries.Nodes {
+				keys = append(keys, e.(*EntryNode).Key.Key)
+			}
+			return keys, nil
+		default:
+			return nil, t.queryError(path, n, "table")
+		}
+	}
+
+	keys := []string{}
+	seen := map[string]bool{}
+	add := func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	for _, e := range t.entries(table) {
+		add(e.Key.Key)
+	}
+	for _, node := range t.Root.Nodes[t.element(table):] {
+		if g, ok := node.(*EntryGroupNode); ok {
+			if k := g.KeyGroup.StringKeys(); len(k) > len(table) && hasPrefix(k, table) {
+				add(k[len(table)])
+			}
+		}
+	}
+	return keys, nil
+}
+
+// GetString returns the string at path and its position.
+func (t *Tree) GetString(path string) (string, Pos, error) {
+	n, ok := t.Get(path).(*StringNode)
+	if !ok {
+		return "", 0, t.queryError(path, t.Get(path), "string")
+	}
+	return n.Text, n.Pos, nil
+}
+
+// GetInt returns the integer at path and its position.
+func (t *Tree) GetInt(path string) (int64, Pos, error) {
+	n, ok := t.Get(path).(*NumberNode)
+	if !ok || !n.IsInt {
+		return 0, 0, t.queryError(path, t.Get(path), "integer")
+	}
+	if n.Overflow {
+		location, _ := t.ErrorContext(n)
+		return 0, 0, fmt.Errorf("toml: %s (%s): integer %s overflows int64", path, location, n.Text)
+	}
+	return n.Int, n.Pos, nil
+}
+
+// GetFloat returns the float at path and its position.
+func (t *Tree) GetFloat(path string) (float64, Pos, error) {
+	n, ok := t.Get(path).(*NumberNode)
+	if !ok || !n.IsFloat {
+		return 0, 0, t.queryError(path, t.Get(path), "float")
+	}
+	return n.Float, n.Pos, nil
+}
+
+// GetBool returns the boolean at path and its position.
+func (t *Tree) GetBool(path string) (bool, Pos, error) {
+	n, ok := t.Get(path).(*BoolNode)
+	if !ok {
+		return false, 0, t.queryError(path, t.Get(path), "bool")
+	}
+	return n.True, n.Pos, nil
+}
+
+// GetTime returns the datetime at path and its position.
+func (t *Tree) GetTime(path string) (time.Time, Pos, error) {
+	n, ok := t.Get(path).(*DatetimeNode)
+	if !ok {
+		return time.Time{}, 0, t.queryError(path, t.Get(path), "datetime")
+	}
+	return n.Time, n.Pos, nil
+}
+
+// GetArray returns the element nodes of the array at path and its position.
+func (t *Tree) GetArray(path string) ([]Node, Pos, error) {
+	n, ok := t.Get(path).(*ArrayNode)
+	if !ok {
+		return nil, 0, t.queryError(path, t.Get(path), "array")
+	}
+	return n.Array.Nodes, n.Pos, nil
+}
+
+// queryError describes why the node n found at path is not of the wanted kind.
+func (t *Tree) queryError(path string, n Node, want string) error {
+	if n == nil {
+		return fmt.Errorf("toml: %s is not defined", path)
+	}
+	location, _ := t.ErrorContext(n)
+	return fmt.Errorf("toml: %s (%s) is %s, want %s", path, location, nodeKind(n), want)
+}
+
+// nodeKind returns the name of the TOML type of n.
+func nodeKind(n Node) string {
+	switch n := n.(type) {
+	case *BoolNode:
+		return "bool"
+	case *StringNode:
+		return "string"
+	case *NumberNode:
+		if n.IsInt {
+			return "integer"
+		}
+		return "float"
+	case *DatetimeNode:
+		return "datetime"
+	case *ArrayNode:
+		return "array"
+	case *EntryGroupNode, *InlineTableNode:
+		return "table"
+	}
+	return "value"
+}
+
+// splitPath splits a dotted path into its keys, unquoting quoted keys.
+func splitPath(path string) ([]string, error) {
+	keys := []string{}
+	rest := path
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		var key string
+		switch {
+		case strings.HasPrefix(rest, `"`), strings.HasPrefix(rest, "'"):
+			i := quotedLen(rest)
+			if i < 0 {
+				return nil, fmt.Errorf("toml: unterminated quoted key in path %q", path)
+			}
+			if rest[0] == '\'' {
+				key = rest[1 : i-1]
+			} else {
+				var err error
+				if key, err = strconv.Unquote(rest[:i]); err != nil {
+					return nil, fmt.Errorf("toml: bad quoted key in path %q", path)
+				}
+			}
+			rest = rest[i:]
+		default:
+			i := strings.IndexByte(rest, keyGroupSep)
+			if i < 0 {
+				i = len(rest)
+			}
+			key = strings.TrimRight(rest[:i], " \t")
+			if key == "" {
+				return nil, fmt.Errorf("toml: empty key in path %q", path)
+			}
+			rest = rest[i:]
+		}
+		keys = append(keys, key)
+
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			return keys, nil
+		}
+		if rest[0] != keyGroupSep {
+			return nil, fmt.Errorf("toml: expected '.' after key %q in path %q", key, path)
+		}
+		rest = rest[1:]
+	}
+}
+
+// quotedLen returns the length of the quoted string at the start of s,
+// quotes included, or -1 if it is not terminated.
+func quotedLen(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return i + 1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,88 @@
+package toml
+
+import (
+	"reflect"
+	"testing"
+)
+
+var queryDoc = `name = "app"
+[server]
+host = "localhost"
+port = 8080
+debug = true
+ratio = 0.5
+[server.tls]
+started = 1979-05-27T07:32:00Z
+ports = [1, 2]
+[client]
+`
+
+func TestQuery(t *testing.T) {
+	tree, err := Parse(queryDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s, pos, err := tree.GetString("server.host"); err != nil || s != "localhost" || tree.text[pos:pos+11] != `"localhost"` {
+		t.Errorf("GetString = %q, %d, %v", s, pos, err)
+	}
+	if i, _, err := tree.GetInt("server . port"); err != nil || i != 8080 {
+		t.Errorf("GetInt = %d, %v", i, err)
+	}
+	if b, _, err := tree.GetBool(`"server".debug`); err != nil || !b {
+		t.Errorf("GetBool = %v, %v", b, err)
+	}
+	if f, _, err := tree.GetFloat("server.ratio"); err != nil || f != 0.5 {
+		t.Errorf("GetFloat = %v, %v", f, err)
+	}
+	if tm, _, err := tree.GetTime("server.tls.started"); err != nil || tm.Year() != 1979 {
+		t.Errorf("GetTime = %v, %v", tm, err)
+	}
+	if a, _, err := tree.GetArray("server.tls.ports"); err != nil || len(a) != 2 {
+		t.Errorf("GetArray = %v, %v", a, err)
+	}
+	if _, _, err := tree.GetInt("server.host"); err == nil || err.Error() != "toml: server.host (3:7) is string, want integer" {
+		t.Errorf("GetInt on string: %v", err)
+	}
+	if _, _, err := tree.GetString("server.missing"); err == nil {
+		t.Errorf("GetString on missing key succeeded")
+	}
+	if !tree.Has("client") || tree.Has("server.tls.missing") {
+		t.Errorf("Has is wrong")
+	}
+
+	if keys, err := tree.Keys(""); err != nil || !reflect.DeepEqual(keys, []string{"name", "server", "client"}) {
+		t.Errorf("Keys(\"\") = %v, %v", keys, err)
+	}
+	if keys, err := tree.Keys("server"); err != nil || !reflect.DeepEqual(keys, []string{"host", "port", "debug", "ratio", "tls"}) {
+		t.Errorf("Keys(server) = %v, %v", keys, err)
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		path string
+		keys []string
+	}{
+		{"a", []string{"a"}},
+		{"a.b.c", []string{"a", "b", "c"}},
+		{`a."b.c".d`, []string{"a", "b.c", "d"}},
+		{`'x y'. z`, []string{"x y", "z"}},
+		{`"a\"b"`, []string{`a"b`}},
+		{"a..b", nil},
+		{`"a`, nil},
+		{`"a"b`, nil},
+	}
+	for _, tt := range tests {
+		keys, err := splitPath(tt.path)
+		if tt.keys == nil {
+			if err == nil {
+				t.Errorf("splitPath(%q) = %q, want error", tt.path, keys)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(keys, tt.keys) {
+			t.Errorf("splitPath(%q) = %q, %v, want %q", tt.path, keys, err, tt.keys)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package toml
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// A union describes the concrete types of an interface type registered
+// with RegisterUnion.
+type union struct {
+	key   string                  // the discriminator key
+	types map[string]reflect.Type // concrete types by discriminator value
+}
+
+var (
+	unionsMu sync.RWMutex
+	unions   = make(map[reflect.Type]*union)
+)
+
+// RegisterUnion makes tables decoded into values of the interface type
+// iface choose their concrete type by the string value of their key
+// entry, looked up in types. The encoder writes the key back for values
+// of these types.
+//
+// The discriminator entry is decoded into the concrete value too if it
+// has a field for key. RegisterUnion panics if iface is not an interface
+// type or a type in types does not implement it.
+func RegisterUnion(iface reflect.Type, key string, types map[string]reflect.Type) {
+	if iface.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("toml: RegisterUnion of non-interface type %s", iface))
+	}
+	u := &union{key: key, types: make(map[string]reflect.Type)}
+	for name, t := range types {
+		if !t.Implements(iface) {
+			panic(fmt.Sprintf("toml: RegisterUnion: %s does not implement %s", t, iface))
+		}
+		u.types[name] = t
+	}
+	unionsMu.Lock()
+	defer unionsMu.Unlock()
+	unions[iface] = u
+}
+
+// unionOf returns the union registered for t, or nil.
+func unionOf(t reflect.Type) *union {
+	if t.Kind() != reflect.Interface {
+		return nil
+	}
+	unionsMu.RLock()
+	defer unionsMu.RUnlock()
+	return unions[t]
+}
+
+// name returns the discriminator value of the concrete type t.
+func (u *union) name(t reflect.Type) (string, bool) {
+	for name, ut := range u.types {
+		if ut == t {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// union decodes the table n into v, an interface of union u, as the
+// concrete type named by its discriminator.
+func (d *decode) union(v reflect.Value, u *union, n *InlineTableNode) {
+	var name Node
+	for _, e := range n.Entries.Nodes {
+		if e := e.(*EntryNode); e.Key.Key == u.key {
+			name = e.Value
+		}
+	}
+	s, ok := name.(*StringNode)
+	if !ok {
+		d.failf(ErrMissingField, "missing %s key selecting the %s", u.key, v.Type())
+	}
+	t, ok := u.types[s.Text]
+	if !ok {
+		d.failf(ErrInvalidValue, "unknown %s %q for %s", u.key, s.Text, v.Type())
+	}
+
+	newv := reflect.New(t).Elem()
+	d.defaults(newv)
+	for _, e := range n.Entries.Nodes {
+		e := e.(*EntryNode)
+		if e.Key.Key == u.key {
+			if _, ok := d.findField(d.indirect(newv), u.key); !ok {
+				continue
+			}
+		}
+		d.entry(newv, e)
+	}
+	v.Set(newv)
+}
+
+// A unionTable is a value of a union written as a table, with its
+// discriminator first.
+type unionTable struct {
+	key   string
+	name  string
+	value reflect.Value
+}
+
+var unionTableType = reflect.TypeOf(unionTable{})
+
+// unionValue returns v as a unionTable if it is an interface of a
+// registered union, and v otherwise.
+func (e *encode) unionValue(v reflect.Value) reflect.Value {
+	if !v.IsValid() || v.Kind() != reflect.Interface || v.IsNil() {
+		return v
+	}
+	u := unionOf(v.Type())
+	if u == nil {
+		return v
+	}
+	name, ok := u.name(v.Elem().Type())
+	if !ok {
+		e.errorf("toml: type %s is not registered for %s", v.Elem().Type(), v.Type())
+	}
+	return reflect.ValueOf(unionTable{u.key, name, v.Elem()})
+}
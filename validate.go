@@ -0,0 +1,233 @@
+package toml
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A Validator checks its own value. The decoder calls Validate after
+// decoding, on the values a value holds before the value itself.
+type Validator interface {
+	Validate() error
+}
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// A ValidationError describes a decoded value that failed validation.
+type ValidationError struct {
+	Path    string // path of the value in the document: servers[0].port
+	Line    int    // line of the value, or 0 if the document does not set it
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	switch {
+	case e.Path == "":
+		return "toml: " + e.Message
+	case e.Line == 0:
+		return "toml: " + e.Path + ": " + e.Message
+	}
+	return fmt.Sprintf("toml: %s (line %d): %s", e.Path, e.Line, e.Message)
+}
+
+// Unwrap returns ErrInvalidValue.
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidValue
+}
+
+// ValidationErrors is the error Decode returns when decoded values fail
+// validation, listing every failure in the order of the fields.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := []string{}
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the failures listed.
+func (e ValidationErrors) Unwrap() []error {
+	errs := []error{}
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// validate checks v and the values it holds against their validate tags
+// and Validate methods, bottom-up, and returns the failures.
+//
+// A validate tag lists rules separated by commas:
+//
+//	min=N, max=N  bounds of a number, or of the length of a string,
+//	              slice or map; other kinds fail them
+//	oneof=a b c   the value, formatted, is one of the words
+//	nonempty      the value is not zero, empty or nil
+//	regexp=RE     a string matches RE; the rule takes the rest of the tag
+func (d *decode) validate(v reflect.Value) error {
+	errs := ValidationErrors{}
+	d.pos = -1
+	d.validateValue(v, &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func (d *decode) validateValue(v reflect.Value, errs *ValidationErrors) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			d.validateValue(v.Elem(), errs)
+		}
+		return
+	case reflect.Struct:
+		for _, f := range typeFields(v.Type(), d.naming) {
+			fv := v.Field(f.index)
+			d.push(f.name)
+			d.validateValue(fv, errs)
+			if tag := v.Type().Field(f.index).Tag.Get("validate"); tag != "" {
+				d.validateTag(fv, tag, errs)
+			}
+			d.pop()
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			d.push(fmt.Sprintf("[%d]", i))
+			d.validateValue(v.Index(i), errs)
+			d.pop()
+		}
+	case reflect.Map:
+		e := &encode{}
+		for _, k := range v.MapKeys() {
+			d.push(e.mapKey(k))
+			d.validateValue(v.MapIndex(k), errs)
+			d.pop()
+		}
+	}
+
+	if !v.CanAddr() {
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		v = c
+	}
+	if v.Addr().Type().Implements(validatorType) {
+		if err := v.Addr().Interface().(Validator).Validate(); err != nil {
+			d.invalid(errs, err.Error())
+		}
+	}
+}
+
+// validateTag checks v against the rules of its validate tag, up to the
+// first one v fails.
+func (d *decode) validateTag(v reflect.Value, tag string, errs *ValidationErrors) {
+	for n := len(*errs); tag != "" && len(*errs) == n; {
+		var rule string
+		if strings.HasPrefix(tag, "regexp=") {
+			rule, tag = tag, ""
+		} else {
+			rule, tag, _ = strings.Cut(tag, ",")
+		}
+		name, arg, _ := strings.Cut(rule, "=")
+
+		if name == "nonempty" {
+			if isEmpty(v) {
+				d.invalid(errs, "must not be empty")
+			}
+			continue
+		}
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return
+			}
+			v = v.Elem()
+		}
+		switch name {
+		case "min", "max":
+			bound, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				d.failf(nil, "bad validate rule %q", rule)
+			}
+			n, length, ok := measure(v)
+			switch {
+			case !ok:
+				d.invalid(errs, fmt.Sprintf("%s does not apply to %s", name, v.Type()))
+			case name == "min" && n < bound && length:
+				d.invalid(errs, fmt.Sprintf("length must be at least %s", arg))
+			case name == "min" && n < bound:
+				d.invalid(errs, fmt.Sprintf("must be at least %s", arg))
+			case name == "max" && n > bound && length:
+				d.invalid(errs, fmt.Sprintf("length must be at most %s", arg))
+			case name == "max" && n > bound:
+				d.invalid(errs, fmt.Sprintf("must be at most %s", arg))
+			}
+		case "oneof":
+			s := fmt.Sprint(v.Interface())
+			found := false
+			for _, w := range strings.Fields(arg) {
+				found = found || w == s
+			}
+			if !found {
+				d.invalid(errs, fmt.Sprintf("%q is not one of %s", s, strings.Join(strings.Fields(arg), ", ")))
+			}
+		case "regexp":
+			re, err := regexp.Compile(arg)
+			if err != nil {
+				d.failf(nil, "bad validate rule %q: %v", rule, err)
+			}
+			if v.Kind() == reflect.String && !re.MatchString(v.String()) {
+				d.invalid(errs, fmt.Sprintf("%q does not match %s", v.String(), arg))
+			}
+		default:
+			d.failf(nil, "unknown validate rule %q", rule)
+		}
+	}
+}
+
+// invalid adds a failure of the current value to errs. Its path is the
+// path in the document of the closest value the document sets.
+func (d *decode) invalid(errs *ValidationErrors, msg string) {
+	err := &ValidationError{Path: pathString(d.path), Message: msg}
+	for i := len(d.canon); i > 0; i-- {
+		loc, ok := d.locations[pathString(d.canon[:i])]
+		if !ok {
+			continue
+		}
+		err.Path = pathString(append(loc.path[:len(loc.path):len(loc.path)], d.canon[i:]...))
+		if i == len(d.canon) {
+			err.Line = d.line(loc.pos)
+		}
+		break
+	}
+	*errs = append(*errs, err)
+}
+
+// measure returns the number v holds, or its length and true for strings
+// and collections. It returns false as ok for other kinds.
+func measure(v reflect.Value) (n float64, length, ok bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, true
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true, true
+	}
+	return 0, false, false
+}
+
+// isEmpty reports whether v is zero, nil or of length zero.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,98 @@
+package toml
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type validateServer struct {
+	Host  string   `validate:"nonempty,regexp=^[a-z.]+$"`
+	Port  int      `validate:"min=1,max=65535"`
+	Tags  []string `validate:"max=2"`
+	Level string   `validate:"oneof=debug info warn"`
+}
+
+func (s *validateServer) Validate() error {
+	if s.Port == 22 {
+		return errors.New("port 22 is reserved")
+	}
+	return nil
+}
+
+type validateConfig struct {
+	Servers []validateServer
+	Backup  *validateServer
+}
+
+func (c validateConfig) Validate() error {
+	if len(c.Servers) == 0 {
+		return errors.New("no servers")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	doc := `[[servers]]
+host = "a.example"
+port = 80
+level = "info"
+
+[[servers]]
+HOST = "B"
+port = 70000
+tags = ["x", "y", "z"]
+level = "trace"
+
+[[servers]]
+host = "c"
+port = 22
+level = "warn"
+`
+	var c validateConfig
+	err := Unmarshal([]byte(doc), &c)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got %v, want ValidationErrors", err)
+	}
+	want := []string{
+		`toml: servers[1].HOST (line 7): "B" does not match ^[a-z.]+$`,
+		`toml: servers[1].port (line 8): must be at most 65535`,
+		`toml: servers[1].tags (line 9): length must be at most 2`,
+		`toml: servers[1].level (line 10): "trace" is not one of debug, info, warn`,
+		`toml: servers[2] (line 12): port 22 is reserved`,
+	}
+	got := []string{}
+	for _, e := range errs {
+		got = append(got, e.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got errors\n%q\nwant\n%q", got, want)
+	}
+	if c.Servers[0].Port != 80 {
+		t.Errorf("values not decoded: %+v", c)
+	}
+
+	c = validateConfig{}
+	err = Unmarshal([]byte("[backup]\nport = 0\nlevel = \"info\"\n"), &c)
+	if err == nil || err.Error() != "toml: backup.Host: must not be empty\ntoml: backup.port (line 2): must be at least 1\ntoml: no servers" {
+		t.Errorf("got %v", err)
+	}
+
+	var bad struct {
+		N int `validate:"between=1 2"`
+	}
+	if err := Unmarshal(nil, &bad); err == nil || err.Error() != `toml: N: unknown validate rule "between=1 2"` {
+		t.Errorf("unknown rule: %v", err)
+	}
+
+	var kinds struct {
+		On   bool      `validate:"min=1"`
+		When time.Time `validate:"max=2"`
+	}
+	err = Unmarshal([]byte("on = true\n"), &kinds)
+	if err == nil || err.Error() != "toml: on (line 1): min does not apply to bool\ntoml: When: max does not apply to time.Time" {
+		t.Errorf("unsupported kinds: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package toml
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses a node tree in depth-first order: It starts by calling
+// v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor
+// w for each of the non-nil children of node, followed by a call of
+// w.Visit(nil).
+func Walk(node Node, v Visitor) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *ListNode:
+		for _, c := range n.Nodes {
+			Walk(c, v)
+		}
+	case *EntryGroupNode:
+		Walk(n.KeyGroup, v)
+		Walk(n.Entries, v)
+	case *KeyGroupNode:
+		Walk(n.Keys, v)
+	case *EntryNode:
+		Walk(n.Key, v)
+		Walk(n.Value, v)
+	case *ArrayNode:
+		Walk(n.Array, v)
+	case *InlineTableNode:
+		Walk(n.Entries, v)
+	}
+
+	v.Visit(nil)
+}
+
+// Inspect traverses a node tree in depth-first order: It starts by
+// calling f(path, node); node must not be nil. If f returns true, Inspect
+// invokes f recursively for each of the non-nil children of node.
+//
+// path holds the keys leading to the node from the root table: the table
+// keys for an entry group and its key group, and the table keys plus the
+// key name for an entry, its key and its value. Entries of an inline
+// table extend the path of the table; array elements share the path of
+// their array. f must not retain path.
+func Inspect(node Node, f func(path []string, n Node) bool) {
+	inspect([]string{}, node, f)
+}
+
+func inspect(path []string, node Node, f func([]string, Node) bool) {
+	switch n := node.(type) {
+	case *EntryGroupNode:
+		path = n.KeyGroup.StringKeys()
+	case *EntryNode:
+		path = append(path[:len(path):len(path)], n.Key.Key)
+	}
+	if !f(path, node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *ListNode:
+		for _, c := range n.Nodes {
+			inspect(path, c, f)
+		}
+	case *EntryGroupNode:
+		inspect(path, n.KeyGroup, f)
+		inspect(path, n.Entries, f)
+	case *KeyGroupNode:
+		inspect(path, n.Keys, f)
+	case *EntryNode:
+		inspect(path, n.Key, f)
+		inspect(path, n.Value, f)
+	case *ArrayNode:
+		inspect(path, n.Array, f)
+	case *InlineTableNode:
+		inspect(path, n.Entries, f)
+	}
+}
@@ -0,0 +1,67 @@
+package toml
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var walkDoc = `a = 1
+[b.c]
+d = [2, 3]
+`
+
+type countVisitor map[NodeType]int
+
+func (c countVisitor) Visit(n Node) Visitor {
+	if n != nil {
+		c[n.Type()]++
+	}
+	return c
+}
+
+func TestWalk(t *testing.T) {
+	tree, err := Parse(walkDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := countVisitor{}
+	Walk(tree.Root, c)
+	if c[NodeEntry] != 2 || c[NodeNumber] != 3 || c[NodeKey] != 4 || c[NodeArray] != 1 {
+		t.Errorf("counts = %v", c)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	tree, err := Parse(walkDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	Inspect(tree.Root, func(path []string, n Node) bool {
+		if n, ok := n.(*NumberNode); ok {
+			got = append(got, fmt.Sprintf("%s=%s", strings.Join(path, "."), n))
+		}
+		return true
+	})
+	want := []string{"a=1", "b.c.d=2", "b.c.d=3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	tree, err := Parse(walkDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := tree.Copy()
+	if !reflect.DeepEqual(c.Root, tree.Root) {
+		t.Fatalf("copy differs from original")
+	}
+	c.Get("b.c.d").(*ArrayNode).Array.Nodes[0].(*NumberNode).Int = 5
+	if tree.Get("b.c.d").(*ArrayNode).Array.Nodes[0].(*NumberNode).Int != 2 {
+		t.Errorf("copy shares nodes with original")
+	}
+}